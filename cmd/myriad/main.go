@@ -1,25 +1,112 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ty-bnn/myriad/pkg/generator"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
 	"github.com/ty-bnn/myriad/pkg/parser"
 	"github.com/ty-bnn/myriad/pkg/tokenizer"
 	"github.com/ty-bnn/myriad/pkg/utils"
 )
 
+// 複数回指定できるフラグ (-I, --vars)
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// main の引数、変数を上書きする (-D name=value)
+type defineFlag []vars.Var
+
+func (d *defineFlag) String() string {
+	defines := make([]string, len(*d))
+	for i, define := range *d {
+		defines[i] = define.Name + "=" + define.Value.(values.Literal).Value
+	}
+	return strings.Join(defines, ",")
+}
+
+func (d *defineFlag) Set(define string) error {
+	name, value, ok := strings.Cut(define, "=")
+	if !ok || name == "" {
+		return errors.New(fmt.Sprintf("%s is not name=value", define))
+	}
+	*d = append(*d, vars.Var{Name: name, Value: values.Literal{Kind: values.LITERAL, Value: value}})
+	return nil
+}
+
+// env() で読める環境変数 (-allow-env は複数指定でき、カンマ区切りでもよい)
+type allowEnvFlag map[string]bool
+
+func (a allowEnvFlag) String() string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (a allowEnvFlag) Set(names string) error {
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			a[name] = true
+		}
+	}
+	return nil
+}
+
 func main() {
+	var (
+		includeDirs listFlag
+		varsFiles   listFlag
+		defines     defineFlag
+	)
+	allowEnv := allowEnvFlag{}
+	flag.Var(&includeDirs, "I", "add a directory to the library search path")
+	flag.Var(allowEnv, "allow-env", "allow env() to read only the named environment variables")
+	flag.Var(&varsFiles, "vars", "set the arguments and variables of main from a JSON file")
+	flag.Var(&defines, "D", "set an argument or a variable of main (name=value)")
+	flag.Parse()
+
+	// -allow-env を1つも指定しなければ全ての環境変数を読める
+	allowEnvSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "allow-env" {
+			allowEnvSet = true
+		}
+	})
+
+	if flag.NArg() < 1 {
+		fmt.Println("usage: myriad [-I dir]... [-allow-env name]... [-D name=value]... [--vars file.json]... file.my [arg]...")
+		os.Exit(1)
+	}
+	filePath := flag.Arg(0)
+
+	// -I で指定したディレクトリ、MYRIAD_PATH の順に探す
+	searchPath := append([]string(includeDirs), filepath.SplitList(os.Getenv("MYRIAD_PATH"))...)
+
 	// Myriadファイルから全ての行を読む
-	data, err := utils.ReadLinesFromFile(os.Args[1])
+	data, err := utils.ReadLinesFromFile(filePath)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
 	// トークナイズ
-	t := tokenizer.NewTokenizer(data, os.Args[1])
+	t := tokenizer.NewTokenizer(data, filePath)
 	err = t.Tokenize()
 	if err != nil {
 		fmt.Println(err)
@@ -27,7 +114,8 @@ func main() {
 	}
 
 	// パース
-	p := parser.NewParser(t.Tokens, os.Args[1])
+	p := parser.NewParser(t.Tokens, filePath)
+	p.SearchPath = searchPath
 	err = p.Parse()
 	if err != nil {
 		fmt.Println(err)
@@ -36,6 +124,13 @@ func main() {
 
 	// Dockerfile生成
 	g := generator.NewGenerator(p.FuncToCodes)
+	g.SearchPath = searchPath
+	g.Args = flag.Args()[1:]
+	g.VarsFiles = varsFiles
+	g.Defines = defines
+	if allowEnvSet {
+		g.AllowEnv = allowEnv
+	}
 	err = g.Generate()
 	if err != nil {
 		fmt.Println(err)
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+)
+
+// arrayMethod evaluates unique(), reverse() or filter() and returns the new array.
+func (g *Generator) arrayMethod(vTable []vars.Var, method values.ArrayMethod) (values.Value, error) {
+	target, err := g.evalValue(vTable, method.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getItems(vTable, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []values.Value
+	switch method.Method {
+	case values.UNIQUE:
+		// 最初に現れた要素を残す
+		seen := make(map[string]bool)
+		for _, item := range items {
+			elem, err := getLiteral(vTable, item)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("semantic error: unique takes an array of strings or numbers"))
+			}
+			key := fmt.Sprintf("%d:%s", item.GetKind(), elem)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, item)
+		}
+	case values.REVERSE:
+		for i := len(items) - 1; i >= 0; i-- {
+			result = append(result, items[i])
+		}
+	case values.FILTER:
+		for _, item := range items {
+			// 引数は条件の中だけで使えるよう、呼び出し元の変数表を複製して加える
+			scope := append(vTable[:len(vTable):len(vTable)], vars.Var{Name: method.Param, Value: item})
+			node, err := g.resolveCondition(scope, method.Predicate)
+			if err != nil {
+				return nil, err
+			}
+			ok, err := evalCondition(scope, node)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return itemsToValue(result)
+}
+
+// slice returns the part of an array or a string between Start and End.
+func (g *Generator) slice(vTable []vars.Var, slice values.Slice) (values.Value, error) {
+	target, err := g.evalValue(vTable, slice.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	// 文字列は文字単位で切り出す
+	var runes []rune
+	var items []values.Value
+	length := 0
+	if target.GetKind() == values.LITERAL {
+		runes = []rune(target.(values.Literal).Value)
+		length = len(runes)
+	} else {
+		items, err = getItems(vTable, target)
+		if err != nil {
+			return nil, err
+		}
+		length = len(items)
+	}
+
+	start, end := 0, length
+	if slice.Start != nil {
+		if start, err = g.evalNumber(vTable, slice.Start); err != nil {
+			return nil, err
+		}
+	}
+	if slice.End != nil {
+		if end, err = g.evalNumber(vTable, slice.End); err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || end < start || length < end {
+		return nil, errors.New(fmt.Sprintf("semantic error: [%d:%d] is out of range for %s with length %d", start, end, slice.Target.GetName(), length))
+	}
+
+	if target.GetKind() == values.LITERAL {
+		return values.Literal{Kind: values.LITERAL, Value: string(runes[start:end])}, nil
+	}
+
+	return itemsToValue(items[start:end])
+}
+
+// evalNumber resolves the function calls in target and evaluates it as a number.
+func (g *Generator) evalNumber(vTable []vars.Var, target values.Value) (int, error) {
+	value, err := g.resolveCalls(vTable, target)
+	if err != nil {
+		return 0, err
+	}
+
+	return getNumber(vTable, value)
+}
+
+// itemsToValue makes an array value from the items.
+// 文字列だけの場合はliterals、それ以外はJSONの配列として扱う
+func itemsToValue(items []values.Value) (values.Value, error) {
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.GetKind() != values.LITERAL {
+			break
+		}
+		strs = append(strs, item.(values.Literal).Value)
+	}
+	if len(strs) == len(items) {
+		return values.Literals{Kind: values.LITERALS, Values: strs}, nil
+	}
+
+	elems := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		elem, err := valueToJSON(item)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+
+	return values.Array{Kind: values.ARRAY, Value: elems}, nil
+}
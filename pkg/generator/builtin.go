@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+)
+
+// builtinFunc receives the evaluated args and returns the value of a builtin function.
+// 引数の数はパーサで確かめてある
+type builtinFunc func(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error)
+
+var builtinFuncs = map[string]builtinFunc{
+	"keys":          builtinKeys,
+	"len":           builtinLen,
+	"has":           builtinHas,
+	"env":           builtinEnv,
+	"replace":       builtinReplace,
+	"toUpper":       builtinToUpper,
+	"toLower":       builtinToLower,
+	"toNumber":      builtinToNumber,
+	"contains":      builtinContains,
+	"join":          builtinJoin,
+	"trimPrefix":    builtinTrimPrefix,
+	"trimSuffix":    builtinTrimSuffix,
+	"padLeft":       builtinPadLeft,
+	"format":        builtinFormat,
+	"matches":       builtinMatches,
+	"capture":       builtinCapture,
+	"semverCompare": builtinSemverCompare,
+	"semverMajor":   builtinSemverMajor,
+	"semverMinor":   builtinSemverMinor,
+}
+
+// keys(m) はmapのキーを昇順に並べた配列を返す
+func builtinKeys(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	mapValue, err := getMap(vTable, args[0])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: keys takes a map"))
+	}
+
+	keys := append([]string{}, mapValue.Keys...)
+	sort.Strings(keys)
+
+	return values.Literals{Kind: values.LITERALS, Values: keys}, nil
+}
+
+// len(x) は配列の要素数、mapのキーの数、文字列の文字数を返す
+func builtinLen(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	switch args[0].GetKind() {
+	case values.LITERAL:
+		return values.Number{Kind: values.NUMBER, Value: utf8.RuneCountInString(args[0].(values.Literal).Value)}, nil
+	case values.MAP:
+		return values.Number{Kind: values.NUMBER, Value: len(args[0].(values.Map).Value.Keys)}, nil
+	}
+
+	items, err := getItems(vTable, args[0])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: len takes an array, a map or a string"))
+	}
+
+	return values.Number{Kind: values.NUMBER, Value: len(items)}, nil
+}
+
+// has(m, key) はmapがキーを持つかを返す (null の場合は偽)
+func builtinHas(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	key, err := getLiteral(vTable, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if args[0].GetKind() == values.NULL {
+		return values.Bool{Kind: values.BOOL, Value: false}, nil
+	}
+
+	mapValue, err := getMap(vTable, args[0])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: has takes a map"))
+	}
+
+	_, ok := mapValue.Get(key)
+	return values.Bool{Kind: values.BOOL, Value: ok}, nil
+}
+
+// defaultValue evaluates default(x, fallback).
+// x が null か、キーが無い場合だけ fallback を評価して返す
+func (g *Generator) defaultValue(vTable []vars.Var, args []values.Value) (values.Value, error) {
+	value, err := g.evalValue(vTable, args[0])
+	if err == nil && value.GetKind() != values.NULL {
+		return value, nil
+	}
+
+	var missing *missingError
+	if err != nil && !errors.As(err, &missing) {
+		return nil, err
+	}
+
+	return g.evalValue(vTable, args[1])
+}
+
+// env(name) は環境変数の値を返す
+// env(name, default) は環境変数が無い場合に default を返す
+func builtinEnv(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	name, err := getLiteral(vTable, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// 許可していない環境変数は設定されていないものとして扱う
+	value, ok := os.LookupEnv(name)
+	allowed := g.AllowEnv == nil || g.AllowEnv[name]
+	if ok && allowed {
+		return values.Literal{Kind: values.LITERAL, Value: value}, nil
+	}
+
+	if len(args) == 2 {
+		fallback, err := getLiteral(vTable, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return values.Literal{Kind: values.LITERAL, Value: fallback}, nil
+	}
+
+	if !allowed {
+		return nil, errors.New(fmt.Sprintf("semantic error: environment variable %s is not allowed", name))
+	}
+	return nil, errors.New(fmt.Sprintf("semantic error: environment variable %s is not set", name))
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+)
+
+// matches(s, pattern) は s が正規表現 pattern にマッチするかを返す
+func builtinMatches(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	str, re, err := g.regexpArgs(vTable, "matches", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Bool{Kind: values.BOOL, Value: re.MatchString(str)}, nil
+}
+
+// capture(s, pattern) は pattern のグループにマッチした部分を返す
+// 名前付きグループがあれば名前をキーにしたmap、無ければ配列を返す
+// マッチしない場合は空の配列、mapを返す
+func builtinCapture(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	str, re, err := g.regexpArgs(vTable, "capture", args)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := re.FindStringSubmatch(str)
+
+	names := re.SubexpNames()
+	if hasNamedGroup(names) {
+		object := values.NewObject()
+		for i, name := range names {
+			if name != "" && groups != nil {
+				object.Set(name, groups[i])
+			}
+		}
+		return values.Map{Kind: values.MAP, Value: object}, nil
+	}
+
+	captured := []string{}
+	if len(groups) == 1 {
+		// グループが無い場合はマッチした部分全体を返す
+		captured = groups
+	} else if len(groups) > 1 {
+		captured = groups[1:]
+	}
+
+	return values.Literals{Kind: values.LITERALS, Values: captured}, nil
+}
+
+// regexpArgs returns the string and the compiled pattern passed to a regexp builtin.
+// 同じパターンは一度だけコンパイルする
+func (g *Generator) regexpArgs(vTable []vars.Var, name string, args []values.Value) (string, *regexp.Regexp, error) {
+	str, err := getLiteral(vTable, args[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	pattern, err := getLiteral(vTable, args[1])
+	if err != nil {
+		return "", nil, err
+	}
+
+	re, ok := g.regexps[pattern]
+	if !ok {
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return "", nil, errors.New(fmt.Sprintf("semantic error: %s has an invalid pattern: %s", name, err))
+		}
+		g.regexps[pattern] = re
+	}
+
+	return str, re, nil
+}
+
+func hasNamedGroup(names []string) bool {
+	for _, name := range names {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+	"github.com/ty-bnn/myriad/pkg/utils"
+)
+
+// semverCompare(v, constraint) は v が ">=1.21" のような制約を満たすかを返す
+func builtinSemverCompare(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "semverCompare", args)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := utils.MatchVersion(strs[0], strs[1])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: semverCompare: %s", err))
+	}
+
+	return values.Bool{Kind: values.BOOL, Value: ok}, nil
+}
+
+// semverMajor(v) はメジャーバージョンを返す
+func builtinSemverMajor(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	return versionPart(vTable, "semverMajor", args, 0)
+}
+
+// semverMinor(v) はマイナーバージョンを返す
+func builtinSemverMinor(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	return versionPart(vTable, "semverMinor", args, 1)
+}
+
+func versionPart(vTable []vars.Var, name string, args []values.Value, i int) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := utils.ParseVersion(strs[0])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: %s: %s", name, err))
+	}
+
+	return values.Number{Kind: values.NUMBER, Value: v.Part(i)}, nil
+}
+
+// sortVersions returns the tags sorted in ascending order of version.
+// 同じバージョンのタグ (1.20 と 1.20-alpine など) は文字列の順に並べる
+func sortVersions(tags []string) ([]string, error) {
+	versions := make(map[string]utils.Version)
+	for _, tag := range tags {
+		v, err := utils.ParseVersion(tag)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("semantic error: sortSemver: %s", err))
+		}
+		versions[tag] = v
+	}
+
+	sorted := append([]string{}, tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if cmp := utils.CompareVersions(versions[sorted[i]], versions[sorted[j]], 3); cmp != 0 {
+			return cmp < 0
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted, nil
+}
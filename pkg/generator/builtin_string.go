@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+)
+
+// replace(s, old, new) は s 中の old を全て new に置き換える
+func builtinReplace(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "replace", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}, nil
+}
+
+// toUpper(s) は s を大文字にする
+func builtinToUpper(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "toUpper", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.ToUpper(strs[0])}, nil
+}
+
+// toLower(s) は s を小文字にする
+func builtinToLower(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "toLower", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.ToLower(strs[0])}, nil
+}
+
+// toNumber(s) は "18" のような10進数の文字列を数値にする
+func builtinToNumber(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	if args[0].GetKind() == values.NUMBER {
+		return args[0], nil
+	}
+
+	strs, err := getLiteralArgs(vTable, "toNumber", args)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(strs[0]))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: toNumber: %q is not a number", strs[0]))
+	}
+
+	return values.Number{Kind: values.NUMBER, Value: number}, nil
+}
+
+// contains(s, sub) は s が sub を含むかを返す
+func builtinContains(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "contains", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Bool{Kind: values.BOOL, Value: strings.Contains(strs[0], strs[1])}, nil
+}
+
+// join(arr, sep) は配列の要素を sep で繋げる
+func builtinJoin(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	items, err := getItems(vTable, args[0])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: join takes an array"))
+	}
+
+	elems := make([]string, len(items))
+	for i, item := range items {
+		elems[i], err = getLiteral(vTable, item)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("semantic error: join takes an array of strings"))
+		}
+	}
+
+	sep, err := getLiteral(vTable, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.Join(elems, sep)}, nil
+}
+
+// trimPrefix(s, prefix) は s の先頭の prefix を1つだけ取り除く
+// trimLeft と違い、prefix を文字の集合としては扱わない
+func builtinTrimPrefix(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "trimPrefix", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.TrimPrefix(strs[0], strs[1])}, nil
+}
+
+// trimSuffix(s, suffix) は s の末尾の suffix を1つだけ取り除く
+func builtinTrimSuffix(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	strs, err := getLiteralArgs(vTable, "trimSuffix", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: strings.TrimSuffix(strs[0], strs[1])}, nil
+}
+
+// padLeft(s, width) は s の左を空白で埋めて width 文字にする
+// padLeft(s, width, pad) は pad で埋める
+func builtinPadLeft(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	str, err := getLiteral(vTable, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	width, err := getNumber(vTable, args[1])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("semantic error: padLeft takes a number as width"))
+	}
+
+	pad := " "
+	if len(args) == 3 {
+		pad, err = getLiteral(vTable, args[2])
+		if err != nil {
+			return nil, err
+		}
+		if pad == "" {
+			return nil, errors.New(fmt.Sprintf("semantic error: padLeft cannot pad with an empty string"))
+		}
+	}
+
+	var padding strings.Builder
+	for n := utf8.RuneCountInString(str); n < width; n++ {
+		r, _ := utf8.DecodeRuneInString(pad[padding.Len()%len(pad):])
+		padding.WriteRune(r)
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: padding.String() + str}, nil
+}
+
+// format(f, args...) は f の %s, %d などを args で置き換える
+func builtinFormat(g *Generator, vTable []vars.Var, args []values.Value) (values.Value, error) {
+	format, err := getLiteral(vTable, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	fmtArgs := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		switch arg.GetKind() {
+		case values.NUMBER:
+			fmtArgs[i] = arg.(values.Number).Value
+		case values.BOOL:
+			fmtArgs[i] = arg.(values.Bool).Value
+		default:
+			str, err := getLiteral(vTable, arg)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("semantic error: format cannot format arg %d", i+1))
+			}
+			fmtArgs[i] = str
+		}
+	}
+
+	// 書式と引数が合わない場合、Sprintf は %!d(string=a) のように埋め込むのでエラーにする
+	result := fmt.Sprintf(format, fmtArgs...)
+	if strings.Contains(result, "%!") && !strings.Contains(fmt.Sprint(fmtArgs...), "%!") {
+		return nil, errors.New(fmt.Sprintf("semantic error: format %q does not match the args", format))
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: result}, nil
+}
+
+// getLiteralArgs evaluates all the args of a builtin function as strings.
+func getLiteralArgs(vTable []vars.Var, name string, args []values.Value) ([]string, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, err := getLiteral(vTable, arg)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("semantic error: %s takes strings", name))
+		}
+		strs[i] = str
+	}
+
+	return strs, nil
+}
@@ -3,9 +3,12 @@ package generator
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/ty-bnn/myriad/pkg/utils"
+	"github.com/ty-bnn/myriad/pkg/yaml"
 
 	"github.com/ty-bnn/myriad/pkg/model/vars"
 
@@ -18,10 +21,13 @@ func (g *Generator) Generate() error {
 
 	fmt.Println("Generating...")
 
-	//mainArgs := os.Args[3:]
+	args, err := g.mainArgs()
+	if err != nil {
+		return err
+	}
 	g.funcPtr = "main"
 
-	g.RawCodes, err = g.callFunc(nil)
+	g.RawCodes, _, err = g.callFunc(args)
 	if err != nil {
 		return err
 	}
@@ -35,33 +41,461 @@ func (g *Generator) Generate() error {
 	return nil
 }
 
-func (g *Generator) callFunc(args []values.Value) ([]string, error) {
+// callFunc runs the function pointed by funcPtr.
+// 出力したDockerfileの行と、return文の戻り値を区別して返す
+func (g *Generator) callFunc(args []values.Value) ([]string, values.Value, error) {
 	funcCodes, ok := g.funcToCodes[g.funcPtr]
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("semantic error: %s is not defined", g.funcPtr))
+		return nil, nil, errors.New(fmt.Sprintf("semantic error: %s is not defined", displayName(g.funcPtr)))
 	}
 
 	var vTable []vars.Var
 
-	// 引数を変数として定義
-	for _, arg := range args {
-		if len(funcCodes) <= g.index || funcCodes[g.index].GetKind() != codes.DEFINE {
-			return nil, errors.New(fmt.Sprintf("semantic error: %s got too many args", g.funcPtr))
+	// 引数宣言を集める
+	var params []codes.Argument
+	for g.index < len(funcCodes) && funcCodes[g.index].GetKind() == codes.ARGUMENT {
+		params = append(params, funcCodes[g.index].(codes.Argument))
+		g.index++
+	}
+
+	// 位置引数、名前付き引数の順に対応する引数へ割り当てる
+	bound := make([]values.Value, len(params))
+	for i, arg := range args {
+		if arg.GetKind() != values.NAMEDARG {
+			if i >= len(params) {
+				return nil, nil, errors.New(fmt.Sprintf("semantic error: %s got too many args", displayName(g.funcPtr)))
+			}
+			bound[i] = arg
+			continue
 		}
 
-		defCode := funcCodes[g.index].(codes.Define)
-		vTable = append(vTable, vars.Var{Name: defCode.Key, Value: arg})
+		namedArg := arg.(values.NamedArg)
+		index := -1
+		for j, param := range params {
+			if param.Key == namedArg.Name {
+				index = j
+				break
+			}
+		}
+		if index < 0 {
+			return nil, nil, errors.New(fmt.Sprintf("semantic error: %s has no argument named %s", displayName(g.funcPtr), namedArg.Name))
+		}
+		if bound[index] != nil {
+			return nil, nil, errors.New(fmt.Sprintf("semantic error: %s got multiple values for %s", displayName(g.funcPtr), namedArg.Name))
+		}
+		bound[index] = namedArg.Value
+	}
 
-		g.index++
+	// 引数を変数として定義
+	// 既定値はそれより前の引数を参照できるよう、呼び出された関数の中で評価する
+	for i, param := range params {
+		value := bound[i]
+		if value == nil {
+			if param.Default == nil {
+				return nil, nil, errors.New(fmt.Sprintf("semantic error: %s is missing argument %s", displayName(g.funcPtr), param.Key))
+			}
+
+			var err error
+			value, err = g.evalValue(vTable, param.Default)
+			if err != nil {
+				return nil, nil, withPos(param.Pos, err)
+			}
+		}
+
+		vTable = append(vTable, vars.Var{Name: param.Key, Value: value})
 	}
 
 	// コードブロック
 	rowCodes, err := g.codeBlock(vTable)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retValue := g.retValue
+	g.retValue = nil
+	g.isReturned = false
+
+	return rowCodes, retValue, nil
+}
+
+// invoke calls the function and restores the state of the caller.
+func (g *Generator) invoke(funcName string, args []values.Value) ([]string, values.Value, error) {
+	funcStack := g.funcPtr
+	g.funcPtr = funcName
+	indexStack := g.index
+	g.index = 0
+
+	// default() がエラーを握りつぶしても続きを実行できるよう、エラーの場合も元に戻す
+	defer func() {
+		g.funcPtr = funcStack
+		g.index = indexStack
+	}()
+
+	return g.callFunc(args)
+}
+
+// resolveCalls calls the functions used in target and replaces them with their return values.
+// 関数呼び出しを含まない値はそのまま返す
+func (g *Generator) resolveCalls(vTable []vars.Var, target values.Value) (values.Value, error) {
+	if target == nil {
+		return nil, nil
+	}
+
+	var err error
+	switch target.GetKind() {
+	case values.FUNCCALL:
+		call := target.(values.FuncCall)
+		args, err := g.evalArgs(vTable, call.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		rawCodes, retValue, err := g.invoke(call.Name, args)
+		if err != nil {
+			return nil, err
+		}
+		if len(rawCodes) > 0 {
+			return nil, errors.New(fmt.Sprintf("semantic error: %s outputs Dockerfile lines and cannot be used as a value", displayName(call.Name)))
+		}
+		if retValue == nil {
+			return nil, errors.New(fmt.Sprintf("semantic error: %s does not return a value", displayName(call.Name)))
+		}
+		return retValue, nil
+	case values.JSONUNMARSHAL:
+		load := target.(values.JsonUnmarshal)
+		path, err := g.evalLiteral(vTable, load.Path)
+		if err != nil {
+			return nil, err
+		}
+		return g.loadJSON(load.Dir, path)
+	case values.CSVLOAD:
+		load := target.(values.CsvLoad)
+		path, err := g.evalLiteral(vTable, load.Path)
+		if err != nil {
+			return nil, err
+		}
+		return g.loadCSV(load.Dir, path)
+	case values.DOTENVLOAD:
+		load := target.(values.DotenvLoad)
+		path, err := g.evalLiteral(vTable, load.Path)
+		if err != nil {
+			return nil, err
+		}
+		return g.loadDotenv(load.Dir, path)
+	case values.YAMLUNMARSHAL:
+		load := target.(values.YamlUnmarshal)
+		path, err := g.evalLiteral(vTable, load.Path)
+		if err != nil {
+			return nil, err
+		}
+		index := 0
+		if load.Document != nil {
+			document, err := g.resolveCalls(vTable, load.Document)
+			if err != nil {
+				return nil, err
+			}
+			if index, err = getNumber(vTable, document); err != nil {
+				return nil, err
+			}
+		}
+		return g.loadYAML(load.Dir, path, index)
+	case values.BUILTINCALL:
+		call := target.(values.BuiltinCall)
+		// default は引数を評価する前に呼び出す
+		if call.Name == "default" {
+			return g.defaultValue(vTable, call.Args)
+		}
+
+		args, err := g.evalArgs(vTable, call.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		builtin, ok := builtinFuncs[call.Name]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("semantic error: %s is not defined", call.Name))
+		}
+		return builtin(g, vTable, args)
+	case values.ARRAYMETHOD:
+		return g.arrayMethod(vTable, target.(values.ArrayMethod))
+	case values.SLICE:
+		return g.slice(vTable, target.(values.Slice))
+	case values.ADDSTRING:
+		add := target.(values.AddString)
+		vls := make([]values.Value, len(add.Values))
+		for i, v := range add.Values {
+			vls[i], err = g.resolveCalls(vTable, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		add.Values = vls
+		return add, nil
+	case values.TRIMSTRING:
+		trim := target.(values.TrimString)
+		if trim.Target, err = g.resolveCalls(vTable, trim.Target); err != nil {
+			return nil, err
+		}
+		if trim.Trim, err = g.resolveCalls(vTable, trim.Trim); err != nil {
+			return nil, err
+		}
+		return trim, nil
+	case values.SPLITSTRING:
+		split := target.(values.SplitString)
+		if split.Target, err = g.resolveCalls(vTable, split.Target); err != nil {
+			return nil, err
+		}
+		if split.Sep, err = g.resolveCalls(vTable, split.Sep); err != nil {
+			return nil, err
+		}
+		return split, nil
+	case values.ARITHMETIC:
+		arith := target.(values.Arithmetic)
+		if arith.Left, err = g.resolveCalls(vTable, arith.Left); err != nil {
+			return nil, err
+		}
+		if arith.Right, err = g.resolveCalls(vTable, arith.Right); err != nil {
+			return nil, err
+		}
+		return arith, nil
+	case values.RANGE:
+		r := target.(values.Range)
+		if r.Start, err = g.resolveCalls(vTable, r.Start); err != nil {
+			return nil, err
+		}
+		if r.End, err = g.resolveCalls(vTable, r.End); err != nil {
+			return nil, err
+		}
+		return r, nil
+	case values.MAPVALUE:
+		mapValue := target.(values.MapValue)
+		keys := make([]values.Value, len(mapValue.Keys))
+		for i, key := range mapValue.Keys {
+			keys[i], err = g.resolveCalls(vTable, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mapValue.Keys = keys
+		return mapValue, nil
+	case values.MAPLITERAL:
+		mapLiteral := target.(values.MapLiteral)
+		vls := make([]values.Value, len(mapLiteral.Values))
+		for i, v := range mapLiteral.Values {
+			vls[i], err = g.resolveCalls(vTable, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mapLiteral.Values = vls
+		return mapLiteral, nil
+	case values.CONDITION:
+		cond := target.(values.Condition)
+		node, err := g.resolveCondition(vTable, cond.Node)
+		if err != nil {
+			return nil, err
+		}
+		cond.Node = node
+		return cond, nil
+	}
+
+	return target, nil
+}
+
+// loadData reads and decodes the data file at path, relative to dir or the search path.
+// 一度読んだファイルは生成が終わるまで再利用する
+func (g *Generator) loadData(dir string, path string, format string, decode func([]byte) (interface{}, error)) (interface{}, string, error) {
+	resolved, err := utils.ResolvePath(path, dir, g.SearchPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := filepath.Abs(resolved)
+	if err != nil {
+		key = filepath.Clean(resolved)
+	}
+	// 同じファイルを別の形式で読む場合もあるので、形式ごとに分ける
+	key = format + ":" + key
+
+	data, ok := g.dataCache[key]
+	if !ok {
+		bytes, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("failed to open %s", resolved))
+		}
+
+		data, err = decode(bytes)
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("failed to unmarshal %s: %s", resolved, err))
+		}
+		markSource(data, resolved)
+		g.dataCache[key] = data
+	}
+
+	return data, resolved, nil
+}
+
+// loadJSON reads the JSON file at path.
+func (g *Generator) loadJSON(dir string, path string) (values.Value, error) {
+	data, resolved, err := g.loadData(dir, path, "json", utils.UnmarshalJSON)
 	if err != nil {
 		return nil, err
 	}
 
-	return rowCodes, nil
+	return dataValue(data, resolved)
+}
+
+// loadYAML reads the document at index in the YAML file at path.
+func (g *Generator) loadYAML(dir string, path string, index int) (values.Value, error) {
+	data, resolved, err := g.loadData(dir, path, "yaml", func(bytes []byte) (interface{}, error) {
+		return yaml.Unmarshal(bytes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := data.([]interface{})
+	if index < 0 || len(docs) <= index {
+		return nil, errors.New(fmt.Sprintf("%s has no document %d", resolved, index))
+	}
+
+	return dataValue(docs[index], resolved)
+}
+
+// loadCSV reads the CSV file at path as an array of maps keyed by the header.
+func (g *Generator) loadCSV(dir string, path string) (values.Value, error) {
+	data, resolved, err := g.loadData(dir, path, "csv", utils.UnmarshalCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataValue(data, resolved)
+}
+
+// loadDotenv reads the .env file at path as a map.
+func (g *Generator) loadDotenv(dir string, path string) (values.Value, error) {
+	data, resolved, err := g.loadData(dir, path, "dotenv", utils.UnmarshalDotenv)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataValue(data, resolved)
+}
+
+// evalArgs evaluates the arguments of a function call.
+// 名前付き引数は値だけを評価し、名前を保ったまま返す
+func (g *Generator) evalArgs(vTable []vars.Var, args []values.Value) ([]values.Value, error) {
+	var evaluated []values.Value
+	for _, arg := range args {
+		if arg.GetKind() == values.NAMEDARG {
+			namedArg := arg.(values.NamedArg)
+			v, err := g.evalValue(vTable, namedArg.Value)
+			if err != nil {
+				return nil, err
+			}
+			namedArg.Value = v
+			evaluated = append(evaluated, namedArg)
+			continue
+		}
+
+		v, err := g.evalValue(vTable, arg)
+		if err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, v)
+	}
+
+	return evaluated, nil
+}
+
+// resolveCondition applies resolveCalls to all the values in the conditional node.
+func (g *Generator) resolveCondition(vTable []vars.Var, node values.ConditionalNode) (values.ConditionalNode, error) {
+	var err error
+	if node.Var, err = g.resolveCalls(vTable, node.Var); err != nil {
+		return node, err
+	}
+	if node.Left != nil {
+		left, err := g.resolveCondition(vTable, *node.Left)
+		if err != nil {
+			return node, err
+		}
+		node.Left = &left
+	}
+	if node.Right != nil {
+		right, err := g.resolveCondition(vTable, *node.Right)
+		if err != nil {
+			return node, err
+		}
+		node.Right = &right
+	}
+
+	return node, nil
+}
+
+// evalValue resolves the function calls in target and evaluates it.
+func (g *Generator) evalValue(vTable []vars.Var, target values.Value) (values.Value, error) {
+	value, err := g.resolveCalls(vTable, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return getValue(vTable, value)
+}
+
+// evalLiteral resolves the function calls in target and evaluates it as a literal.
+func (g *Generator) evalLiteral(vTable []vars.Var, target values.Value) (string, error) {
+	value, err := g.resolveCalls(vTable, target)
+	if err != nil {
+		return "", err
+	}
+
+	return getLiteral(vTable, value)
+}
+
+// embedLiteral evaluates target embedded with {{ }} as a literal.
+// 配列やmapは1つの文字列にできないので、式の名前を添えてエラーにする
+func (g *Generator) embedLiteral(vTable []vars.Var, target values.Value) (string, error) {
+	value, err := g.resolveCalls(vTable, target)
+	if err != nil {
+		return "", err
+	}
+
+	kind := value.GetKind()
+	if kind == values.IDENT {
+		if index, err := getIndex(vTable, value.GetName()); err == nil {
+			kind = vTable[index].Value.GetKind()
+		}
+	}
+
+	switch kind {
+	case values.LITERALS, values.ARRAY, values.SPLITSTRING:
+		return "", errors.New(fmt.Sprintf("semantic error: cannot embed %s because it is an array", embedName(target)))
+	case values.MAP:
+		return "", errors.New(fmt.Sprintf("semantic error: cannot embed %s because it is a map", embedName(target)))
+	}
+
+	return getLiteral(vTable, value)
+}
+
+// embedName returns the name of the embedded expression for error messages.
+func embedName(target values.Value) string {
+	switch target.GetKind() {
+	case values.SLICE:
+		return fmt.Sprintf("the slice of %s", embedName(target.(values.Slice).Target))
+	case values.ARRAYMETHOD:
+		method := target.(values.ArrayMethod)
+		names := map[values.MethodKind]string{values.UNIQUE: "unique", values.REVERSE: "reverse", values.FILTER: "filter"}
+		return fmt.Sprintf("the result of %s() on %s", names[method.Method], embedName(method.Target))
+	case values.FUNCCALL:
+		return fmt.Sprintf("the result of %s", displayName(target.(values.FuncCall).Name))
+	case values.BUILTINCALL:
+		return fmt.Sprintf("the result of %s", target.(values.BuiltinCall).Name)
+	}
+
+	if target.GetName() == "" {
+		return "the value"
+	}
+	return target.GetName()
 }
 
 func (g *Generator) codeBlock(vTable []vars.Var) ([]string, error) {
@@ -84,110 +518,153 @@ func (g *Generator) codeBlock(vTable []vars.Var) ([]string, error) {
 			g.index++
 		case codes.DEFINE:
 			define := code.(codes.Define)
-			value, err := getValue(vTable, define.Value)
+			value, err := g.evalValue(vTable, define.Value)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			vTable = append(vTable, vars.Var{Name: define.Key, Value: value})
 			g.index++
 		case codes.ASSIGN:
 			assign := code.(codes.Assign)
-			value, err := getValue(vTable, assign.Value)
+			value, err := g.evalValue(vTable, assign.Value)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			index, err := getIndex(vTable, assign.Key)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			vTable[index] = vars.Var{Name: assign.Key, Value: value}
 			g.index++
 		case codes.REPLACE:
 			rep := code.(codes.Replace)
-			value, err := getLiteral(vTable, rep.Value)
+			value, err := g.embedLiteral(vTable, rep.Value)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			rawCodes = append(rawCodes, value)
 			g.index++
 		case codes.APPEND:
 			appendCode := code.(codes.Append)
-			elem, err := getLiteral(vTable, appendCode.Element)
+			elem, err := g.evalLiteral(vTable, appendCode.Element)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			index, err := getIndex(vTable, appendCode.Array)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			if vTable[index].Value.GetKind() != values.LITERALS {
-				return nil, errors.New(fmt.Sprintf("semantic error: %s is not array", appendCode.Array))
+				return nil, withPos(code.GetPos(), errors.New(fmt.Sprintf("semantic error: %s is not array", appendCode.Array)))
 			}
 			elements := vTable[index].Value.(values.Literals).Values
 			elements = append(elements, elem)
 			vTable[index].Value = values.Literals{Kind: values.LITERALS, Values: elements}
 			g.index++
+		case codes.ASSIGNELEMENT:
+			assign := code.(codes.AssignElement)
+			target, err := g.resolveCalls(vTable, assign.Target)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			value, err := g.evalValue(vTable, assign.Value)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			index, err := getIndex(vTable, assign.Target.Name)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			mapValue, err := setMapElement(vTable, vTable[index].Value, target.(values.MapValue), value)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			vTable[index].Value = mapValue
+			g.index++
+		case codes.DELETE:
+			deleteCode := code.(codes.Delete)
+			target, err := g.resolveCalls(vTable, deleteCode.Target)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			key, err := g.evalValue(vTable, deleteCode.Key)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			index, err := getIndex(vTable, deleteCode.Target.Name)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			mapValue, err := deleteMapElement(vTable, vTable[index].Value, target.(values.MapValue), key)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
+			}
+			vTable[index].Value = mapValue
+			g.index++
 		case codes.SORT:
 			appendCode := code.(codes.Sort)
 			index, err := getIndex(vTable, appendCode.Array)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			if vTable[index].Value.GetKind() != values.LITERALS {
-				return nil, errors.New(fmt.Sprintf("semantic error: %s is not array", appendCode.Array))
+				return nil, withPos(code.GetPos(), errors.New(fmt.Sprintf("semantic error: %s is not array", appendCode.Array)))
+			}
+			if appendCode.BySemver {
+				sorted, err := sortVersions(vTable[index].Value.(values.Literals).Values)
+				if err != nil {
+					return nil, withPos(code.GetPos(), err)
+				}
+				vTable[index] = vars.Var{Name: appendCode.Array, Value: values.Literals{Kind: values.LITERALS, Values: sorted}}
+			} else {
+				sort.Strings(vTable[index].Value.(values.Literals).Values)
 			}
-			sort.Strings(vTable[index].Value.(values.Literals).Values)
 			g.index++
 		case codes.CALLPROC:
 			callProc := code.(codes.CallProc)
 
-			var args []values.Value
-			for _, arg := range callProc.Args {
-				v, err := getValue(vTable, arg)
-				if err != nil {
-					return nil, err
-				}
-				args = append(args, v)
+			args, err := g.evalArgs(vTable, callProc.Args)
+			if err != nil {
+				return nil, withPos(code.GetPos(), err)
 			}
 
-			funcStack := g.funcPtr
-			g.funcPtr = callProc.ProcName
-			indexStack := g.index
-			g.index = 0
-
-			funcRawCodes, err := g.callFunc(args)
+			// 文として呼び出した場合、戻り値は捨てる
+			funcRawCodes, _, err := g.invoke(callProc.ProcName, args)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 
-			g.funcPtr = funcStack
-			g.index = indexStack
-
 			rawCodes = append(rawCodes, funcRawCodes...)
 			g.index++
 		case codes.IF:
 			ifCodes, err := g.ifBlock(vTable)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			rawCodes = append(rawCodes, ifCodes...)
+			if g.isEscaping() {
+				return rawCodes, nil
+			}
 		case codes.FOR:
 			forCodes, err := g.forBlock(vTable)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			rawCodes = append(rawCodes, forCodes...)
+			if g.isReturned {
+				return rawCodes, nil
+			}
 		case codes.OUTPUT:
 			outCode := code.(codes.Output)
-			outPath, err := getLiteral(vTable, outCode.FilePath)
+			outPath, err := g.evalLiteral(vTable, outCode.FilePath)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 			g.index++
 
 			outCodes, err := g.codeBlock(vTable)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
 			}
 
 			// ENDコード
@@ -197,8 +674,28 @@ func (g *Generator) codeBlock(vTable []vars.Var) ([]string, error) {
 
 			err = utils.WriteFile(outCodes, outPath)
 			if err != nil {
-				return nil, err
+				return nil, withPos(code.GetPos(), err)
+			}
+			if g.isEscaping() {
+				return rawCodes, nil
 			}
+		case codes.RETURN:
+			retCode := code.(codes.Return)
+			if retCode.Value != nil {
+				value, err := g.evalValue(vTable, retCode.Value)
+				if err != nil {
+					return nil, withPos(code.GetPos(), err)
+				}
+				g.retValue = value
+			}
+			g.isReturned = true
+			return rawCodes, nil
+		case codes.BREAK:
+			g.isBreaking = true
+			return rawCodes, nil
+		case codes.CONTINUE:
+			g.isContinuing = true
+			return rawCodes, nil
 		default:
 			return rawCodes, nil
 		}
@@ -217,6 +714,9 @@ func (g *Generator) ifBlock(vTable []vars.Var) ([]string, error) {
 		return nil, err
 	}
 	ifBCodes = append(ifBCodes, ifSecCodes...)
+	if g.isEscaping() {
+		return ifBCodes, nil
+	}
 
 	// ELIFコード
 	for g.index < len(funcCodes) && funcCodes[g.index].GetKind() == codes.ELIF {
@@ -226,6 +726,9 @@ func (g *Generator) ifBlock(vTable []vars.Var) ([]string, error) {
 			return nil, err
 		}
 		ifBCodes = append(ifBCodes, elifSecCodes...)
+		if g.isEscaping() {
+			return ifBCodes, nil
+		}
 	}
 
 	// ELSEコード
@@ -247,7 +750,11 @@ func (g *Generator) ifSection(vTable []vars.Var) ([]string, error) {
 	// IFコード
 	ifCode := funcCodes[g.index].(codes.If)
 	ifCodePtr := g.index
-	ok, err := evalCondition(vTable, ifCode.Condition)
+	condition, err := g.resolveCondition(vTable, ifCode.Condition)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := evalCondition(vTable, condition)
 	if err != nil {
 		return nil, err
 	}
@@ -262,6 +769,9 @@ func (g *Generator) ifSection(vTable []vars.Var) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	if g.isEscaping() {
+		return rowCodes, nil
+	}
 
 	g.index = ifCodePtr + ifCode.True
 
@@ -274,9 +784,13 @@ func (g *Generator) elifSection(vTable []vars.Var) ([]string, error) {
 	// ELIFコード
 	elifCode := funcCodes[g.index].(codes.Elif)
 	elifCodePtr := g.index
-	ok, err := evalCondition(vTable, elifCode.Condition)
+	condition, err := g.resolveCondition(vTable, elifCode.Condition)
 	if err != nil {
-		return nil, err
+		return nil, withPos(elifCode.Pos, err)
+	}
+	ok, err := evalCondition(vTable, condition)
+	if err != nil {
+		return nil, withPos(elifCode.Pos, err)
 	}
 	if !ok {
 		g.index += elifCode.False
@@ -289,6 +803,9 @@ func (g *Generator) elifSection(vTable []vars.Var) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	if g.isEscaping() {
+		return rowCodes, nil
+	}
 
 	g.index = elifCodePtr + elifCode.True
 
@@ -321,7 +838,16 @@ func (g *Generator) forBlock(vTable []vars.Var) ([]string, error) {
 	forCode := funcCodes[g.index].(codes.For)
 	g.index++
 
-	literals, err := getLiterals(vTable, forCode.ArrayValue)
+	arrayValue, err := g.resolveCalls(vTable, forCode.ArrayValue)
+	if err != nil {
+		return nil, err
+	}
+	var keys, items []values.Value
+	if forCode.ValueName != "" {
+		keys, items, err = getPairs(vTable, arrayValue)
+	} else {
+		items, err = getItems(vTable, arrayValue)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -329,11 +855,21 @@ func (g *Generator) forBlock(vTable []vars.Var) ([]string, error) {
 	start := g.index
 	itrName := forCode.ItrName
 
-	for _, literal := range literals {
+	if len(items) == 0 {
+		g.skipBlock()
+		return nil, nil
+	}
+
+	for i, item := range items {
 		var rowCodes []string
 		g.index = start
 
-		vTable = append(vTable, vars.Var{Name: itrName, Value: values.Literal{Kind: values.LITERAL, Value: literal}})
+		if forCode.ValueName != "" {
+			vTable = append(vTable, vars.Var{Name: itrName, Value: keys[i]})
+			vTable = append(vTable, vars.Var{Name: forCode.ValueName, Value: item})
+		} else {
+			vTable = append(vTable, vars.Var{Name: itrName, Value: item})
+		}
 
 		// コードブロック
 		rowCodes, err = g.codeBlock(vTable)
@@ -341,13 +877,57 @@ func (g *Generator) forBlock(vTable []vars.Var) ([]string, error) {
 			return nil, err
 		}
 		forCodes = append(forCodes, rowCodes...)
+		if g.isReturned {
+			return forCodes, nil
+		}
 
 		// ENDコード
-		g.index++
+		if g.isBreaking || g.isContinuing {
+			// 本体の途中で抜けた場合は、本体を読み飛ばしてENDコードの次へ進む
+			g.index = start
+			g.skipBlock()
+		} else {
+			g.index++
+		}
 
 		// for文で定義したイテレータをPOP
-		vTable = vTable[:len(vTable)-1]
+		if forCode.ValueName != "" {
+			vTable = vTable[:len(vTable)-2]
+		} else {
+			vTable = vTable[:len(vTable)-1]
+		}
+
+		if g.isBreaking {
+			g.isBreaking = false
+			break
+		}
+		g.isContinuing = false
 	}
 
 	return forCodes, nil
 }
+
+// isEscaping reports whether a return, break or continue statement is leaving the current block.
+func (g *Generator) isEscaping() bool {
+	return g.isReturned || g.isBreaking || g.isContinuing
+}
+
+// skipBlock moves the index past the END code of the current block without executing it.
+func (g *Generator) skipBlock() {
+	funcCodes := g.funcToCodes[g.funcPtr]
+
+	depth := 0
+	for g.index < len(funcCodes) {
+		switch funcCodes[g.index].GetKind() {
+		case codes.IF, codes.ELIF, codes.ELSE, codes.FOR, codes.OUTPUT:
+			depth++
+		case codes.END:
+			if depth == 0 {
+				g.index++
+				return
+			}
+			depth--
+		}
+		g.index++
+	}
+}
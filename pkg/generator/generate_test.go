@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ty-bnn/myriad/pkg/parser"
+	"github.com/ty-bnn/myriad/pkg/tokenizer"
+)
+
+// 数字の文字列はどの演算子でも自動では数値にならず、toNumber で変換する
+func TestNumericStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "concatenate", value: `v + 1`, want: "181"},
+		{name: "concatenate strings", value: `v + "2"`, want: "182"},
+		{name: "subtract", value: `v - 1`, wantErr: "cannot use v as type number, convert it with toNumber(v)"},
+		{name: "multiply", value: `v * 2`, wantErr: "cannot use v as type number"},
+		{name: "divide", value: `v / 2`, wantErr: "cannot use v as type number"},
+		{name: "modulo", value: `v % 2`, wantErr: "cannot use v as type number"},
+		{name: "compare", value: `v >= 18`, wantErr: "cannot use v as type number"},
+		{name: "converted add", value: `toNumber(v) + 1`, want: "19"},
+		{name: "converted subtract", value: `toNumber(v) - 1`, want: "17"},
+		{name: "converted multiply", value: `toNumber(v) * 2`, want: "36"},
+		{name: "converted compare", value: `toNumber(v) >= 18`, want: "true"},
+		{name: "number", value: `toNumber(n) + 1`, want: "19"},
+		{name: "not a number", value: `toNumber("1.20")`, wantErr: `toNumber: "1.20" is not a number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "main() {\n    v := \"18\"\n    n := 18\n    x := " + tt.value + "\n    {{- RUN {{ x }} -}}\n}\n"
+			tk := tokenizer.NewTokenizer(src, "t.my")
+			if err := tk.Tokenize(); err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+			p := parser.NewParser(tk.Tokens, "t.my")
+			if err := p.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			g := NewGenerator(p.FuncToCodes)
+			err := g.Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if got := strings.TrimSpace(strings.Join(g.RawCodes, "")); got != "RUN "+tt.want {
+				t.Errorf("Generate() = %q, want %q", got, "RUN "+tt.want)
+			}
+		})
+	}
+}
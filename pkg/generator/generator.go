@@ -1,7 +1,11 @@
 package generator
 
 import (
+	"regexp"
+
 	"github.com/ty-bnn/myriad/pkg/model/codes"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
 )
 
 type Generator struct {
@@ -10,10 +14,31 @@ type Generator struct {
 	funcPtr     string
 	index       int
 	commandPtr  string
+	// return文で関数を抜ける際の戻り値
+	retValue   values.Value
+	isReturned bool
+	// break文, continue文でfor文の本体を抜ける
+	isBreaking   bool
+	isContinuing bool
+	// SearchPath はデータファイルを探すディレクトリ
+	SearchPath []string
+	// AllowEnv は env() で読める環境変数 (nil の場合は全て読める)
+	AllowEnv map[string]bool
+	// Args は main の位置引数
+	Args []string
+	// VarsFiles, Defines は main の引数、変数を上書きする (Defines を優先する)
+	VarsFiles []string
+	Defines   []vars.Var
+	// 読み込んだデータファイル (形式とパスをキーにする)
+	dataCache map[string]interface{}
+	// コンパイルした正規表現 (パターンをキーにする)
+	regexps map[string]*regexp.Regexp
 }
 
 func NewGenerator(funcToCodes map[string][]codes.Code) *Generator {
 	return &Generator{
 		funcToCodes: funcToCodes,
+		dataCache:   make(map[string]interface{}),
+		regexps:     make(map[string]*regexp.Regexp),
 	}
 }
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ty-bnn/myriad/pkg/model/codes"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/model/vars"
+	"github.com/ty-bnn/myriad/pkg/utils"
+)
+
+// mainArgs returns the args to call main with.
+// 位置引数は順に、--vars, -D で渡した変数は同じ名前の引数へ割り当てる (位置引数より優先する)
+// 引数に無い変数は main 直下の同じ名前の変数定義を置き換え、定義が無ければ main の先頭で定義する
+func (g *Generator) mainArgs() ([]values.Value, error) {
+	overrides, err := g.overrideVars()
+	if err != nil {
+		return nil, err
+	}
+
+	funcCodes := g.funcToCodes["main"]
+
+	// 引数宣言 (引数名から何番目の引数かを引く)
+	paramEnd := 0
+	params := make(map[string]int)
+	for paramEnd < len(funcCodes) && funcCodes[paramEnd].GetKind() == codes.ARGUMENT {
+		params[funcCodes[paramEnd].(codes.Argument).Key] = paramEnd
+		paramEnd++
+	}
+
+	var args []values.Value
+	for _, arg := range g.Args {
+		args = append(args, values.Literal{Kind: values.LITERAL, Value: arg})
+	}
+
+	var others []vars.Var
+	for _, override := range overrides {
+		i, ok := params[override.Name]
+		if !ok {
+			others = append(others, override)
+			continue
+		}
+		if i < len(g.Args) {
+			// 位置引数で渡した値は -D, --vars の値で上書きする
+			args[i] = override.Value
+			continue
+		}
+		args = append(args, values.NamedArg{Kind: values.NAMEDARG, Name: override.Name, Value: override.Value})
+	}
+	if len(others) == 0 {
+		return args, nil
+	}
+
+	newCodes := append([]codes.Code{}, funcCodes[:paramEnd]...)
+	body := append([]codes.Code{}, funcCodes[paramEnd:]...)
+
+	// main 直下の変数定義を置き換える
+	defined := make(map[string]bool)
+	depth := 0
+	for i, code := range body {
+		switch code.GetKind() {
+		case codes.IF, codes.ELIF, codes.ELSE, codes.FOR, codes.OUTPUT:
+			depth++
+		case codes.END:
+			depth--
+		case codes.DEFINE:
+			define := code.(codes.Define)
+			if depth != 0 {
+				continue
+			}
+			for _, other := range others {
+				if other.Name == define.Key {
+					define.Value = other.Value
+					body[i] = define
+					defined[define.Key] = true
+				}
+			}
+		}
+	}
+
+	for _, other := range others {
+		if !defined[other.Name] {
+			newCodes = append(newCodes, codes.Define{Kind: codes.DEFINE, Key: other.Name, Value: other.Value})
+		}
+	}
+	g.funcToCodes["main"] = append(newCodes, body...)
+
+	return args, nil
+}
+
+// overrideVars collects the variables given by --vars and -D.
+// 後から指定したものを優先する
+func (g *Generator) overrideVars() ([]vars.Var, error) {
+	var overrides []vars.Var
+	set := func(name string, value values.Value) {
+		for i, override := range overrides {
+			if override.Name == name {
+				overrides[i].Value = value
+				return
+			}
+		}
+		overrides = append(overrides, vars.Var{Name: name, Value: value})
+	}
+
+	for _, path := range g.VarsFiles {
+		data, resolved, err := g.loadData("", path, "json", utils.UnmarshalJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		object, ok := data.(*values.Object)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("failed to unmarshal %s: not a map", resolved))
+		}
+		for _, key := range object.Keys {
+			value, ok := jsonToValue(object.Items[key])
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("failed to unmarshal %s: %s cannot be used as a variable", resolved, key))
+			}
+			set(key, value)
+		}
+	}
+
+	for _, define := range g.Defines {
+		set(define.Name, define.Value)
+	}
+
+	return overrides, nil
+}
@@ -3,18 +3,34 @@ package generator
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/ty-bnn/myriad/pkg/model/token"
 
 	"github.com/ty-bnn/myriad/pkg/model/vars"
 
-	"github.com/ty-bnn/myriad/pkg/model/codes"
 	"github.com/ty-bnn/myriad/pkg/model/values"
 )
 
-func evalCondition(vTable []vars.Var, root codes.ConditionalNode) (bool, error) {
-	if _, ok := codes.CompOperator[root.Operator]; ok {
+// evalCondition evaluates the conditional node.
+// Falseフラグが立っている節は結果を反転する
+func evalCondition(vTable []vars.Var, root values.ConditionalNode) (bool, error) {
+	result, err := evalNode(vTable, root)
+	if err != nil {
+		return false, err
+	}
+
+	return result != root.False, nil
+}
+
+func evalNode(vTable []vars.Var, root values.ConditionalNode) (bool, error) {
+	if root.Operator == values.TRUTH {
+		return getBool(vTable, root.Var)
+	}
+
+	if _, ok := values.CompOperator[root.Operator]; ok {
 		eq, err := isEqual(vTable, root)
 		if err != nil {
 			return false, err
@@ -27,60 +43,255 @@ func evalCondition(vTable []vars.Var, root codes.ConditionalNode) (bool, error)
 		return false, err
 	}
 
-	rEq, err := evalCondition(vTable, *root.Right)
-	if err != nil {
-		return false, err
+	// 左辺で結果が決まる場合は右辺を評価しない
+	if root.Operator == values.OR && lEq {
+		return true, nil
 	}
-
-	if root.Operator == codes.OR {
-		return lEq || rEq, nil
+	if root.Operator == values.AND && !lEq {
+		return false, nil
 	}
 
-	return lEq && rEq, nil
+	return evalCondition(vTable, *root.Right)
 }
 
-func isEqual(vTable []vars.Var, node codes.ConditionalNode) (bool, error) {
+func isEqual(vTable []vars.Var, node values.ConditionalNode) (bool, error) {
 	left, _ := getLiteral(vTable, node.Left.Var)
 	right, _ := getLiteral(vTable, node.Right.Var)
 
 	switch node.Operator {
-	case codes.EQUAL:
+	case values.EQUAL:
 		return left == right, nil
-	case codes.NOTEQUAL:
+	case values.NOTEQUAL:
 		return left != right, nil
-	case codes.STARTWITH:
-		if node.False {
-			return !strings.HasPrefix(left, right), nil
-		}
+	case values.STARTWITH:
 		return strings.HasPrefix(left, right), nil
-	case codes.ENDWITH:
-		if node.False {
-			return !strings.HasSuffix(left, right), nil
-		}
+	case values.ENDWITH:
 		return strings.HasSuffix(left, right), nil
+	case values.LESS, values.LESSEQUAL, values.GREATER, values.GREATEREQUAL:
+		return compareNumbers(vTable, node)
+	case values.IN:
+		items, err := getItems(vTable, node.Right.Var)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range items {
+			if elem, err := getLiteral(vTable, item); err == nil && elem == left {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, errors.New(fmt.Sprintf("invalid operator kind"))
+}
+
+func compareNumbers(vTable []vars.Var, node values.ConditionalNode) (bool, error) {
+	left, err := getNumber(vTable, node.Left.Var)
+	if err != nil {
+		return false, err
+	}
+	right, err := getNumber(vTable, node.Right.Var)
+	if err != nil {
+		return false, err
+	}
+
+	switch node.Operator {
+	case values.LESS:
+		return left < right, nil
+	case values.LESSEQUAL:
+		return left <= right, nil
+	case values.GREATER:
+		return left > right, nil
+	case values.GREATEREQUAL:
+		return left >= right, nil
 	}
 
 	return false, errors.New(fmt.Sprintf("invalid operator kind"))
 }
 
+// getBool returns a boolean.
+// bool, condition, ident, map_valueに対応
+func getBool(vTable []vars.Var, target values.Value) (bool, error) {
+	target = normalizeElement(vTable, target)
+
+	// null は偽とする
+	if isNull(vTable, target) {
+		return false, nil
+	}
+
+	switch target.GetKind() {
+	case values.BOOL:
+		return target.(values.Bool).Value, nil
+	case values.CONDITION:
+		return evalCondition(vTable, target.(values.Condition).Node)
+	}
+
+	for i := len(vTable) - 1; i >= 0; i-- {
+		if vTable[i].Name != target.GetName() {
+			continue
+		}
+
+		switch target.GetKind() {
+		case values.IDENT:
+			if vTable[i].Value.GetKind() != values.BOOL {
+				return false, errors.New(fmt.Sprintf("semantic error: cannot use %s as type bool", target.GetName()))
+			}
+			return vTable[i].Value.(values.Bool).Value, nil
+		case values.MAPVALUE:
+			anyValue, err := getMapElement(vTable, vTable[i].Value, target.(values.MapValue))
+			if err != nil {
+				return false, err
+			}
+			boolValue, ok := anyValue.(bool)
+			if !ok {
+				return false, errors.New(fmt.Sprintf("semantic error: value is not type bool"))
+			}
+			return boolValue, nil
+		default:
+			return false, errors.New(fmt.Sprintf("semantic error: value is not type bool"))
+		}
+	}
+
+	return false, errors.New(fmt.Sprintf("semantic error: %s is not declared", target.GetName()))
+}
+
+// getNumber returns a number.
+// number, arithmetic, add_string, ident, map_valueに対応
+func getNumber(vTable []vars.Var, target values.Value) (int, error) {
+	target = normalizeElement(vTable, target)
+
+	switch target.GetKind() {
+	case values.NUMBER:
+		return target.(values.Number).Value, nil
+	case values.ARITHMETIC:
+		arith := target.(values.Arithmetic)
+		left, err := getNumber(vTable, arith.Left)
+		if err != nil {
+			return 0, err
+		}
+		right, err := getNumber(vTable, arith.Right)
+		if err != nil {
+			return 0, err
+		}
+
+		switch arith.Operator {
+		case values.SUB:
+			return left - right, nil
+		case values.MUL:
+			return left * right, nil
+		case values.DIV, values.MOD:
+			if right == 0 {
+				return 0, errors.New(fmt.Sprintf("semantic error: division by zero"))
+			}
+			if arith.Operator == values.DIV {
+				return left / right, nil
+			}
+			return left % right, nil
+		}
+		return 0, errors.New(fmt.Sprintf("invalid operator kind"))
+	case values.ADDSTRING:
+		var sum int
+		for _, value := range target.(values.AddString).Values {
+			number, err := getNumber(vTable, value)
+			if err != nil {
+				return 0, err
+			}
+			sum += number
+		}
+		return sum, nil
+	}
+
+	for i := len(vTable) - 1; i >= 0; i-- {
+		if vTable[i].Name != target.GetName() {
+			continue
+		}
+
+		switch target.GetKind() {
+		case values.IDENT:
+			if vTable[i].Value.GetKind() == values.LITERAL {
+				// 数字の文字列も自動では数値にしない (+ で連結する場合と区別がつかなくなるため)
+				return 0, errors.New(fmt.Sprintf("semantic error: cannot use %s as type number, convert it with toNumber(%s)", target.GetName(), target.GetName()))
+			}
+			if vTable[i].Value.GetKind() != values.NUMBER {
+				return 0, errors.New(fmt.Sprintf("semantic error: cannot use %s as type number", target.GetName()))
+			}
+			return vTable[i].Value.(values.Number).Value, nil
+		case values.MAPVALUE:
+			anyValue, err := getMapElement(vTable, vTable[i].Value, target.(values.MapValue))
+			if err != nil {
+				return 0, err
+			}
+			number, ok := assertionToNumber(anyValue)
+			if !ok {
+				return 0, errors.New(fmt.Sprintf("semantic error: value is not type number"))
+			}
+			return number, nil
+		default:
+			return 0, errors.New(fmt.Sprintf("semantic error: value is not type number"))
+		}
+	}
+
+	return 0, errors.New(fmt.Sprintf("semantic error: %s is not declared", target.GetName()))
+}
+
 // getLiteral returns a literal.
 // 変数のスコープを実現するために、変数表の後ろから変数名を探索する
 // literal, ident, element, map_value, trim_stringに対応
 func getLiteral(vTable []vars.Var, target values.Value) (string, error) {
+	target = normalizeElement(vTable, target)
+
+	// null は空文字列とする
+	if isNull(vTable, target) {
+		return "", nil
+	}
+
 	// 文字列が入っていた場合はそのまま値を返す
 	if target.GetKind() == values.LITERAL {
 		return target.(values.Literal).Value, nil
 	}
 
+	if target.GetKind() == values.BOOL || target.GetKind() == values.CONDITION {
+		boolValue, err := getBool(vTable, target)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(boolValue), nil
+	}
+
+	if target.GetKind() == values.NUMBER || target.GetKind() == values.ARITHMETIC {
+		number, err := getNumber(vTable, target)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(number), nil
+	}
+
 	if target.GetKind() == values.ADDSTRING {
+		// 左から順に評価し、数値同士は加算、文字列が現れて以降は連結する
 		var literals string
-		for _, value := range target.(values.AddString).Values {
+		var sum int
+		isNumber := true
+		for i, value := range target.(values.AddString).Values {
+			if isNumber {
+				number, err := getNumber(vTable, value)
+				if err == nil {
+					sum += number
+					continue
+				}
+				if i > 0 {
+					literals = strconv.Itoa(sum)
+				}
+				isNumber = false
+			}
 			literal, err := getLiteral(vTable, value)
 			if err != nil {
 				return "", err
 			}
 			literals += literal
 		}
+		if isNumber {
+			return strconv.Itoa(sum), nil
+		}
 		return literals, nil
 	}
 
@@ -109,6 +320,12 @@ func getLiteral(vTable []vars.Var, target values.Value) (string, error) {
 
 		switch target.GetKind() {
 		case values.IDENT:
+			if vTable[i].Value.GetKind() == values.NUMBER {
+				return strconv.Itoa(vTable[i].Value.(values.Number).Value), nil
+			}
+			if vTable[i].Value.GetKind() == values.BOOL {
+				return strconv.FormatBool(vTable[i].Value.(values.Bool).Value), nil
+			}
 			if vTable[i].Value.GetKind() != values.LITERAL {
 				return "", errors.New(fmt.Sprintf("semantic error: cannot use %s as type single", target.GetName()))
 			}
@@ -124,27 +341,11 @@ func getLiteral(vTable []vars.Var, target values.Value) (string, error) {
 			}
 			return vTable[i].Value.(values.Literals).Values[index], nil
 		case values.MAPVALUE:
-			if vTable[i].Value.GetKind() != values.MAP {
-				return "", errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
-			}
-
-			keys := target.(values.MapValue).Keys
-			var anyValue interface{} = vTable[i].Value.(values.Map).Value
-			for _, key := range keys {
-				keyValue, err := getLiteral(vTable, key)
-				if err != nil {
-					return "", err
-				}
-				mapValue, ok := anyValue.(map[string]interface{})
-				if !ok {
-					return "", errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
-				anyValue, ok = mapValue[keyValue]
-				if !ok {
-					return "", errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
+			anyValue, err := getMapElement(vTable, vTable[i].Value, target.(values.MapValue))
+			if err != nil {
+				return "", err
 			}
-			strValue, ok := anyValue.(string)
+			strValue, ok := assertionToString(anyValue)
 			if !ok {
 				return "", errors.New(fmt.Sprintf("semantic error: value is not type literal"))
 			}
@@ -154,6 +355,9 @@ func getLiteral(vTable []vars.Var, target values.Value) (string, error) {
 		}
 	}
 
+	if target.GetName() == "" {
+		return "", errors.New(fmt.Sprintf("semantic error: value is not type literal"))
+	}
 	return "", errors.New(fmt.Sprintf("semantic error: %s is not declared", target.GetName()))
 }
 
@@ -192,31 +396,13 @@ func getLiterals(vTable []vars.Var, target values.Value) ([]string, error) {
 				return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
 			}
 
-			var keys []string
-			for key := range vTable[i].Value.(values.Map).Value {
-				keys = append(keys, key)
-			}
+			keys := vTable[i].Value.(values.Map).Value.Keys
 
-			return keys, nil
+			return append([]string{}, keys...), nil
 		case values.MAPVALUE:
-			if vTable[i].Value.GetKind() != values.MAP {
-				return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
-			}
-			keys := target.(values.MapValue).Keys
-			var anyValue interface{} = vTable[i].Value.(values.Map).Value
-			for _, key := range keys {
-				keyValue, err := getLiteral(vTable, key)
-				if err != nil {
-					return nil, err
-				}
-				mapValue, ok := anyValue.(map[string]interface{})
-				if !ok {
-					return nil, errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
-				anyValue, ok = mapValue[keyValue]
-				if !ok {
-					return nil, errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
+			anyValue, err := getMapElement(vTable, vTable[i].Value, target.(values.MapValue))
+			if err != nil {
+				return nil, err
 			}
 			arrayValue, ok := assertionToStringSlice(anyValue)
 			if !ok {
@@ -233,11 +419,28 @@ func getLiterals(vTable []vars.Var, target values.Value) ([]string, error) {
 
 // getMap returns map_value.
 // map, map_valueに対応
-func getMap(vTable []vars.Var, target values.Value) (map[string]interface{}, error) {
+func getMap(vTable []vars.Var, target values.Value) (*values.Object, error) {
 	if target.GetKind() == values.MAP {
 		return target.(values.Map).Value, nil
 	}
 
+	if target.GetKind() == values.MAPLITERAL {
+		mapLiteral := target.(values.MapLiteral)
+		mapValue := values.NewObject()
+		for i, key := range mapLiteral.Keys {
+			value, err := getValue(vTable, mapLiteral.Values[i])
+			if err != nil {
+				return nil, err
+			}
+			elem, err := valueToJSON(value)
+			if err != nil {
+				return nil, err
+			}
+			mapValue.Set(key, elem)
+		}
+		return mapValue, nil
+	}
+
 	for i := len(vTable) - 1; i >= 0; i-- {
 		if vTable[i].Name != target.GetName() {
 			continue
@@ -250,27 +453,11 @@ func getMap(vTable []vars.Var, target values.Value) (map[string]interface{}, err
 			}
 			return vTable[i].Value.(values.Map).Value, nil
 		case values.MAPVALUE:
-			if vTable[i].Value.GetKind() != values.MAP {
-				return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
-			}
-
-			keys := target.(values.MapValue).Keys
-			var anyValue interface{} = vTable[i].Value.(values.Map).Value
-			for _, key := range keys {
-				keyValue, err := getLiteral(vTable, key)
-				if err != nil {
-					return nil, err
-				}
-				mapValue, ok := anyValue.(map[string]interface{})
-				if !ok {
-					return nil, errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
-				anyValue, ok = mapValue[keyValue]
-				if !ok {
-					return nil, errors.New(fmt.Sprintf("semantic error: missing %s in %s as a key", keyValue, target.GetName()))
-				}
+			anyValue, err := getMapElement(vTable, vTable[i].Value, target.(values.MapValue))
+			if err != nil {
+				return nil, err
 			}
-			mapValue, ok := anyValue.(map[string]interface{})
+			mapValue, ok := anyValue.(*values.Object)
 			if !ok {
 				return nil, errors.New(fmt.Sprintf("semantic error: value is not type map"))
 			}
@@ -283,8 +470,322 @@ func getMap(vTable []vars.Var, target values.Value) (map[string]interface{}, err
 	return nil, errors.New(fmt.Sprintf("semantic error: %s is not declared", target.GetName()))
 }
 
+// missingError reports that a key or an index is not in the data.
+// default() はこのエラーの場合に代わりの値を使う
+type missingError struct {
+	msg string
+}
+
+func (e *missingError) Error() string {
+	return e.msg
+}
+
+// hasElement reports whether the map or the array has the key.
+func hasElement(anyValue interface{}, key string) bool {
+	switch v := anyValue.(type) {
+	case *values.Object:
+		_, ok := v.Get(key)
+		return ok
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		return err == nil && 0 <= index && index < len(v)
+	}
+	return false
+}
+
+// isNull reports whether target is null.
+// JSONの null と、? を付けたアクセスでキーが無かった場合が null になる
+func isNull(vTable []vars.Var, target values.Value) bool {
+	switch target.GetKind() {
+	case values.NULL:
+		return true
+	case values.IDENT:
+		index, err := getIndex(vTable, target.GetName())
+		return err == nil && vTable[index].Value.GetKind() == values.NULL
+	case values.MAPVALUE:
+		index, err := getIndex(vTable, target.GetName())
+		if err != nil {
+			return false
+		}
+		anyValue, err := getMapElement(vTable, vTable[index].Value, target.(values.MapValue))
+		return err == nil && anyValue == nil
+	}
+	return false
+}
+
+// getMapElement returns the element of mapVar specified by the keys of target.
+// 配列は数値のキーで要素を取り出す
+func getMapElement(vTable []vars.Var, mapVar values.Value, target values.MapValue) (interface{}, error) {
+	var anyValue interface{}
+	var source string
+	switch mapVar.GetKind() {
+	case values.MAP:
+		anyValue = mapVar.(values.Map).Value
+	case values.ARRAY:
+		anyValue = mapVar.(values.Array).Value
+		source = mapVar.(values.Array).Source
+	case values.LITERALS:
+		anyValue, _ = valueToJSON(mapVar)
+	default:
+		return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
+	}
+
+	// エラーには辿ったキーの並びと、読んだJSONファイルを示す
+	keyPath := target.GetName()
+	missing := func(format string, a ...interface{}) error {
+		msg := fmt.Sprintf(format, a...)
+		if source != "" {
+			msg += fmt.Sprintf(" (%s)", source)
+		}
+		return &missingError{msg: "semantic error: " + msg}
+	}
+
+	for i, key := range target.Keys {
+		keyValue, err := getLiteral(vTable, key)
+		if err != nil {
+			return nil, err
+		}
+
+		// 無くてもよいキーが無い場合は null とする
+		if i < len(target.Optional) && target.Optional[i] && !hasElement(anyValue, keyValue) {
+			return nil, nil
+		}
+
+		switch v := anyValue.(type) {
+		case *values.Object:
+			if v.Source != "" {
+				source = v.Source
+			}
+			var ok bool
+			anyValue, ok = v.Get(keyValue)
+			if !ok {
+				return nil, missing("missing %s in %s as a key", keyValue, keyPath)
+			}
+			keyPath += fmt.Sprintf("[%q]", keyValue)
+		case []interface{}:
+			index, err := strconv.Atoi(keyValue)
+			if err != nil || index < 0 || len(v) <= index {
+				return nil, missing("out of index %s for %s", keyValue, keyPath)
+			}
+			anyValue = v[index]
+			keyPath += fmt.Sprintf("[%d]", index)
+		default:
+			return nil, missing("missing %s in %s as a key", keyValue, keyPath)
+		}
+	}
+
+	return anyValue, nil
+}
+
+// getPairs returns the keys and the values to iterate with for (k, v in target).
+// mapはキーの並び順に、配列は添字と要素の組を返す
+func getPairs(vTable []vars.Var, target values.Value) ([]values.Value, []values.Value, error) {
+	mapValue, err := getMap(vTable, target)
+	if err == nil {
+		var keys, items []values.Value
+		for _, key := range mapValue.Keys {
+			item, ok := jsonToValue(mapValue.Items[key])
+			if !ok {
+				return nil, nil, errors.New(fmt.Sprintf("semantic error: cannot use %s in %s as a value", key, target.GetName()))
+			}
+			keys = append(keys, values.Literal{Kind: values.LITERAL, Value: key})
+			items = append(items, item)
+		}
+		return keys, items, nil
+	}
+
+	items, err := getItems(vTable, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []values.Value
+	for i := range items {
+		keys = append(keys, values.Number{Kind: values.NUMBER, Value: i})
+	}
+	return keys, items, nil
+}
+
+// getArray returns the elements of an array that is not literals.
+func getArray(vTable []vars.Var, target values.Value) ([]interface{}, error) {
+	if target.GetKind() == values.ARRAY {
+		return target.(values.Array).Value, nil
+	}
+
+	index, err := getIndex(vTable, target.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.GetKind() {
+	case values.IDENT:
+		if vTable[index].Value.GetKind() == values.ARRAY {
+			return vTable[index].Value.(values.Array).Value, nil
+		}
+	case values.MAPVALUE:
+		anyValue, err := getMapElement(vTable, vTable[index].Value, target.(values.MapValue))
+		if err != nil {
+			return nil, err
+		}
+		if arrayValue, ok := anyValue.([]interface{}); ok {
+			return arrayValue, nil
+		}
+	}
+
+	return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type array", target.GetName()))
+}
+
+// normalizeElement reads x[0] as a map value when x is not literals.
+// パーサは x[0] を配列要素とするが、JSONの配列やmapの要素も同じ書き方で取り出せるようにする
+func normalizeElement(vTable []vars.Var, target values.Value) values.Value {
+	if target.GetKind() != values.ELEMENT {
+		return target
+	}
+
+	index, err := getIndex(vTable, target.GetName())
+	if err != nil || vTable[index].Value.GetKind() == values.LITERALS {
+		return target
+	}
+
+	element := target.(values.Element)
+	return values.MapValue{
+		Kind: values.MAPVALUE,
+		Name: element.Name,
+		Keys: []values.Value{values.Number{Kind: values.NUMBER, Value: element.Index}},
+	}
+}
+
+// getItems returns the elements to iterate over in a for loop.
+// range, map_valueの数値を保ったまま返し、それ以外はgetLiteralsに委ねる
+func getItems(vTable []vars.Var, target values.Value) ([]values.Value, error) {
+	if target.GetKind() == values.RANGE {
+		start, err := getNumber(vTable, target.(values.Range).Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := getNumber(vTable, target.(values.Range).End)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []values.Value
+		for n := start; n < end; n++ {
+			items = append(items, values.Number{Kind: values.NUMBER, Value: n})
+		}
+		return items, nil
+	}
+
+	target = normalizeElement(vTable, target)
+
+	// null は空の配列とする
+	if isNull(vTable, target) {
+		return nil, nil
+	}
+
+	if target.GetKind() == values.IDENT {
+		index, err := getIndex(vTable, target.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		if vTable[index].Value.GetKind() == values.RANGE {
+			return getItems(vTable, vTable[index].Value)
+		}
+	}
+
+	// JSONの配列は要素をそのままの型で取り出す
+	if target.GetKind() == values.ARRAY || target.GetKind() == values.IDENT || target.GetKind() == values.MAPVALUE {
+		slices, err := getArray(vTable, target)
+		if err == nil {
+			var items []values.Value
+			for _, v := range slices {
+				item, ok := jsonToValue(v)
+				if !ok {
+					return nil, errors.New(fmt.Sprintf("semantic error: value is not type literals"))
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		}
+	}
+
+	literals, err := getLiterals(vTable, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []values.Value
+	for _, literal := range literals {
+		items = append(items, values.Literal{Kind: values.LITERAL, Value: literal})
+	}
+	return items, nil
+}
+
 func getValue(vTable []vars.Var, value values.Value) (values.Value, error) {
-	// getLiteral, getLiterals, getMapを順に回していき、適切なvalueを探す
+	value = normalizeElement(vTable, value)
+
+	if isNull(vTable, value) {
+		return values.Null{Kind: values.NULL}, nil
+	}
+
+	if value.GetKind() == values.MAPVALUE {
+		// JSONの要素はそのままの型で取り出す
+		index, err := getIndex(vTable, value.GetName())
+		if err != nil {
+			return nil, err
+		}
+		anyValue, err := getMapElement(vTable, vTable[index].Value, value.(values.MapValue))
+		if err != nil {
+			return nil, err
+		}
+		if jsonValue, ok := jsonToValue(anyValue); ok {
+			return jsonValue, nil
+		}
+	}
+
+	if value.GetKind() == values.RANGE {
+		// rangeは要素を展開せず、両端を評価して保持する
+		start, err := getNumber(vTable, value.(values.Range).Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := getNumber(vTable, value.(values.Range).End)
+		if err != nil {
+			return nil, err
+		}
+		return values.Range{
+			Kind:  values.RANGE,
+			Start: values.Number{Kind: values.NUMBER, Value: start},
+			End:   values.Number{Kind: values.NUMBER, Value: end},
+		}, nil
+	}
+
+	if value.GetKind() == values.IDENT {
+		// rangeを保持する変数はそのまま受け渡す
+		index, err := getIndex(vTable, value.GetName())
+		if err == nil && vTable[index].Value.GetKind() == values.RANGE {
+			return vTable[index].Value, nil
+		}
+	}
+
+	// getNumber, getBool, getLiteral, getLiterals, getMapを順に回していき、適切なvalueを探す
+	number, err := getNumber(vTable, value)
+	if err == nil {
+		return values.Number{Kind: values.NUMBER, Value: number}, nil
+	}
+	if value.GetKind() == values.ARITHMETIC {
+		// 算術式は数値にしかならないので、数値として評価できなかった理由を返す
+		return nil, err
+	}
+
+	boolValue, err := getBool(vTable, value)
+	if err == nil {
+		return values.Bool{Kind: values.BOOL, Value: boolValue}, nil
+	}
+	if value.GetKind() == values.CONDITION {
+		return nil, err
+	}
+
 	literal, err := getLiteral(vTable, value)
 	if err == nil {
 		return values.Literal{Kind: values.LITERAL, Value: literal}, nil
@@ -300,9 +801,25 @@ func getValue(vTable []vars.Var, value values.Value) (values.Value, error) {
 		return values.Map{Kind: values.MAP, Value: mapLiteral}, nil
 	}
 
+	array, err := getArray(vTable, value)
+	if err == nil {
+		return values.Array{Kind: values.ARRAY, Value: array}, nil
+	}
+
 	return nil, err
 }
 
+// withPos attaches pos to err unless err already has a position.
+// ネストしたブロックで発生したエラーは最も内側のコードの位置を保つ
+func withPos(pos token.Position, err error) error {
+	var tokErr *token.Error
+	if errors.As(err, &tokErr) {
+		return err
+	}
+
+	return &token.Error{Pos: pos, Err: err}
+}
+
 func getIndex(vTable []vars.Var, vName string) (int, error) {
 	for i := len(vTable) - 1; i >= 0; i-- {
 		if vTable[i].Name == vName {
@@ -321,7 +838,7 @@ func assertionToStringSlice(target interface{}) ([]string, bool) {
 
 	var strings []string
 	for _, v := range slices {
-		str, ok := v.(string)
+		str, ok := assertionToString(v)
 		if !ok {
 			return nil, false
 		}
@@ -332,6 +849,217 @@ func assertionToStringSlice(target interface{}) ([]string, bool) {
 	return strings, true
 }
 
+// setMapElement returns a copy of mapVar with the value set at the keys of target.
+// 途中のキーが無い場合は空のmapを作る
+func setMapElement(vTable []vars.Var, mapVar values.Value, target values.MapValue, value values.Value) (values.Value, error) {
+	if mapVar.GetKind() != values.MAP {
+		return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
+	}
+
+	elem, err := valueToJSON(value)
+	if err != nil {
+		return nil, err
+	}
+
+	root := copyJSON(mapVar.(values.Map).Value).(*values.Object)
+	mapValue := root
+	for i, key := range target.Keys {
+		keyValue, err := getLiteral(vTable, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == len(target.Keys)-1 {
+			mapValue.Set(keyValue, elem)
+			break
+		}
+
+		next, ok := mapValue.Get(keyValue)
+		if !ok {
+			next = values.NewObject()
+			mapValue.Set(keyValue, next)
+		}
+		mapValue, ok = next.(*values.Object)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("semantic error: %s in %s is not type map", keyValue, target.GetName()))
+		}
+	}
+
+	return values.Map{Kind: values.MAP, Value: root}, nil
+}
+
+// deleteMapElement returns a copy of mapVar without the key in the map at target.
+// キーが無い場合は何もしない
+func deleteMapElement(vTable []vars.Var, mapVar values.Value, target values.MapValue, key values.Value) (values.Value, error) {
+	if mapVar.GetKind() != values.MAP {
+		return nil, errors.New(fmt.Sprintf("semantic error: cannot use %s as type map", target.GetName()))
+	}
+
+	keyValue, err := getLiteral(vTable, key)
+	if err != nil {
+		return nil, err
+	}
+
+	root := copyJSON(mapVar.(values.Map).Value).(*values.Object)
+	anyValue, err := getMapElement(vTable, values.Map{Kind: values.MAP, Value: root}, target)
+	if err != nil {
+		return nil, err
+	}
+	mapValue, ok := anyValue.(*values.Object)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("semantic error: value is not type map"))
+	}
+	mapValue.Delete(keyValue)
+
+	return values.Map{Kind: values.MAP, Value: root}, nil
+}
+
+// dataValue converts the data loaded from a file to a value.
+func dataValue(data interface{}, source string) (values.Value, error) {
+	switch v := data.(type) {
+	case *values.Object:
+		return values.Map{Kind: values.MAP, Value: v}, nil
+	case []interface{}:
+		return values.Array{Kind: values.ARRAY, Value: v, Source: source}, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("failed to unmarshal %s: not a map or an array", source))
+}
+
+// markSource records the file that the JSON objects in target are loaded from.
+func markSource(target interface{}, source string) {
+	switch v := target.(type) {
+	case *values.Object:
+		v.Source = source
+		for _, elem := range v.Items {
+			markSource(elem, source)
+		}
+	case []interface{}:
+		for _, elem := range v {
+			markSource(elem, source)
+		}
+	}
+}
+
+// copyJSON deeply copies the maps and arrays in target.
+// 変数の値を書き換える前に複製し、同じmapを持つ他の変数に影響しないようにする
+func copyJSON(target interface{}) interface{} {
+	switch v := target.(type) {
+	case *values.Object:
+		copied := values.NewObject()
+		copied.Source = v.Source
+		for _, key := range v.Keys {
+			copied.Set(key, copyJSON(v.Items[key]))
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, elem := range v {
+			copied[i] = copyJSON(elem)
+		}
+		return copied
+	}
+
+	return target
+}
+
+// valueToJSON converts an evaluated value to the same form as the values of JsonUnmarshal.
+func valueToJSON(value values.Value) (interface{}, error) {
+	switch value.GetKind() {
+	case values.NULL:
+		return nil, nil
+	case values.LITERAL:
+		return value.(values.Literal).Value, nil
+	case values.NUMBER:
+		return float64(value.(values.Number).Value), nil
+	case values.BOOL:
+		return value.(values.Bool).Value, nil
+	case values.LITERALS:
+		elems := make([]interface{}, 0, len(value.(values.Literals).Values))
+		for _, elem := range value.(values.Literals).Values {
+			elems = append(elems, elem)
+		}
+		return elems, nil
+	case values.MAP:
+		return value.(values.Map).Value, nil
+	case values.ARRAY:
+		return value.(values.Array).Value, nil
+	case values.RANGE:
+		r := value.(values.Range)
+		elems := make([]interface{}, 0)
+		for i := r.Start.(values.Number).Value; i < r.End.(values.Number).Value; i++ {
+			elems = append(elems, float64(i))
+		}
+		return elems, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("semantic error: value cannot be stored in a map"))
+}
+
+// jsonToValue converts a JSON value to a value keeping its type.
+// 数値・真偽値・null は型を保ち、文字列だけの配列はliteralsにする
+func jsonToValue(target interface{}) (values.Value, bool) {
+	if target == nil {
+		return values.Null{Kind: values.NULL}, true
+	}
+
+	switch v := target.(type) {
+	case *values.Object:
+		return values.Map{Kind: values.MAP, Value: v}, true
+	case []interface{}:
+		// 文字列だけの配列はliteralsとして扱う
+		strs := make([]string, 0, len(v))
+		for _, elem := range v {
+			str, ok := elem.(string)
+			if !ok {
+				return values.Array{Kind: values.ARRAY, Value: v}, true
+			}
+			strs = append(strs, str)
+		}
+		return values.Literals{Kind: values.LITERALS, Values: strs}, true
+	}
+	if number, ok := assertionToNumber(target); ok {
+		return values.Number{Kind: values.NUMBER, Value: number}, true
+	}
+	if boolValue, ok := target.(bool); ok {
+		return values.Bool{Kind: values.BOOL, Value: boolValue}, true
+	}
+	str, ok := assertionToString(target)
+	if !ok {
+		return nil, false
+	}
+
+	return values.Literal{Kind: values.LITERAL, Value: str}, true
+}
+
+// assertionToString returns a JSON string or number as a string.
+func assertionToString(target interface{}) (string, bool) {
+	switch v := target.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		if number, ok := assertionToNumber(v); ok {
+			return strconv.Itoa(number), true
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+// assertionToNumber returns a JSON number as an integer.
+// 小数部を持つ数値は整数として扱わない
+func assertionToNumber(target interface{}) (int, bool) {
+	v, ok := target.(float64)
+	if !ok || v != math.Trunc(v) {
+		return 0, false
+	}
+
+	return int(v), true
+}
+
 func whiteSpaces(word string) string {
 	var spaces string
 	for i := 0; i < len(word)+1; i++ {
@@ -372,3 +1100,9 @@ func shapeRawCodes(codes []string) {
 		}
 	}
 }
+
+// displayName returns the function name as written in the source.
+// インポートした関数の内部名は "ファイル名#関数名" となっている
+func displayName(funcName string) string {
+	return funcName[strings.LastIndex(funcName, "#")+1:]
+}
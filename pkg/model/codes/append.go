@@ -1,9 +1,13 @@
 package codes
 
-import "github.com/ty-bnn/myriad/pkg/model/values"
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
 
 type Append struct {
 	Kind    CodeKind
+	Pos     token.Position
 	Array   string
 	Element values.Value
 }
@@ -11,3 +15,7 @@ type Append struct {
 func (a Append) GetKind() CodeKind {
 	return a.Kind
 }
+
+func (a Append) GetPos() token.Position {
+	return a.Pos
+}
@@ -0,0 +1,23 @@
+package codes
+
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// Argument represents a parameter of a function.
+// Defaultは省略時の値で、nilの場合は呼び出し側で必ず渡す
+type Argument struct {
+	Kind    CodeKind
+	Pos     token.Position
+	Key     string
+	Default values.Value
+}
+
+func (a Argument) GetKind() CodeKind {
+	return a.Kind
+}
+
+func (a Argument) GetPos() token.Position {
+	return a.Pos
+}
@@ -1,9 +1,13 @@
 package codes
 
-import "github.com/ty-bnn/myriad/pkg/model/values"
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
 
 type Assign struct {
 	Kind  CodeKind
+	Pos   token.Position
 	Key   string
 	Value values.Value
 }
@@ -11,3 +15,7 @@ type Assign struct {
 func (a Assign) GetKind() CodeKind {
 	return a.Kind
 }
+
+func (a Assign) GetPos() token.Position {
+	return a.Pos
+}
@@ -0,0 +1,22 @@
+package codes
+
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// AssignElement sets a value to a key of a map, m["k"] = v.
+type AssignElement struct {
+	Kind   CodeKind
+	Pos    token.Position
+	Target values.MapValue
+	Value  values.Value
+}
+
+func (a AssignElement) GetKind() CodeKind {
+	return a.Kind
+}
+
+func (a AssignElement) GetPos() token.Position {
+	return a.Pos
+}
@@ -0,0 +1,16 @@
+package codes
+
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
+type Break struct {
+	Kind CodeKind
+	Pos  token.Position
+}
+
+func (b Break) GetKind() CodeKind {
+	return b.Kind
+}
+
+func (b Break) GetPos() token.Position {
+	return b.Pos
+}
@@ -1,11 +1,13 @@
 package codes
 
 import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
 	"github.com/ty-bnn/myriad/pkg/model/values"
 )
 
 type CallProc struct {
 	Kind     CodeKind
+	Pos      token.Position
 	ProcName string
 	Args     []values.Value
 }
@@ -13,3 +15,7 @@ type CallProc struct {
 func (i CallProc) GetKind() CodeKind {
 	return i.Kind
 }
+
+func (i CallProc) GetPos() token.Position {
+	return i.Pos
+}
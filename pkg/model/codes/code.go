@@ -1,7 +1,10 @@
 package codes
 
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
 type Code interface {
 	GetKind() CodeKind
+	GetPos() token.Position
 }
 
 type CodeKind int
@@ -21,4 +24,10 @@ const (
 	OUTPUT
 	APPEND
 	SORT
+	RETURN
+	ARGUMENT
+	ASSIGNELEMENT
+	DELETE
+	BREAK
+	CONTINUE
 )
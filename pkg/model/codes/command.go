@@ -1,10 +1,17 @@
 package codes
 
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
 type Command struct {
 	Kind    CodeKind
+	Pos     token.Position
 	Content string
 }
 
 func (c Command) GetKind() CodeKind {
 	return c.Kind
 }
+
+func (c Command) GetPos() token.Position {
+	return c.Pos
+}
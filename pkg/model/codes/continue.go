@@ -0,0 +1,16 @@
+package codes
+
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
+type Continue struct {
+	Kind CodeKind
+	Pos  token.Position
+}
+
+func (c Continue) GetKind() CodeKind {
+	return c.Kind
+}
+
+func (c Continue) GetPos() token.Position {
+	return c.Pos
+}
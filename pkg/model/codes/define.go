@@ -1,11 +1,13 @@
 package codes
 
 import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
 	"github.com/ty-bnn/myriad/pkg/model/values"
 )
 
 type Define struct {
 	Kind  CodeKind
+	Pos   token.Position
 	Key   string
 	Value values.Value
 }
@@ -13,3 +15,7 @@ type Define struct {
 func (d Define) GetKind() CodeKind {
 	return d.Kind
 }
+
+func (d Define) GetPos() token.Position {
+	return d.Pos
+}
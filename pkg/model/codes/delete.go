@@ -0,0 +1,22 @@
+package codes
+
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// Delete removes a key from a map, delete(m, "k").
+type Delete struct {
+	Kind   CodeKind
+	Pos    token.Position
+	Target values.MapValue
+	Key    values.Value
+}
+
+func (d Delete) GetKind() CodeKind {
+	return d.Kind
+}
+
+func (d Delete) GetPos() token.Position {
+	return d.Pos
+}
@@ -1,9 +1,16 @@
 package codes
 
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
 type End struct {
 	Kind CodeKind
+	Pos  token.Position
 }
 
 func (e End) GetKind() CodeKind {
 	return e.Kind
 }
+
+func (e End) GetPos() token.Position {
+	return e.Pos
+}
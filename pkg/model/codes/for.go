@@ -1,13 +1,23 @@
 package codes
 
-import "github.com/ty-bnn/myriad/pkg/model/values"
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
 
 type For struct {
-	Kind       CodeKind
-	ItrName    string
+	Kind    CodeKind
+	Pos     token.Position
+	ItrName string
+	// for (k, v in m) の形のときの値の変数名
+	ValueName  string
 	ArrayValue values.Value
 }
 
 func (f For) GetKind() CodeKind {
 	return f.Kind
 }
+
+func (f For) GetPos() token.Position {
+	return f.Pos
+}
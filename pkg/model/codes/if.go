@@ -1,32 +1,30 @@
 package codes
 
-import "github.com/ty-bnn/myriad/pkg/model/values"
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
 
 type (
 	If struct {
 		Kind      CodeKind
-		Condition ConditionalNode
+		Pos       token.Position
+		Condition values.ConditionalNode
 		Jump
 	}
 
 	Elif struct {
 		Kind      CodeKind
-		Condition ConditionalNode
+		Pos       token.Position
+		Condition values.ConditionalNode
 		Jump
 	}
 
 	Else struct {
 		Kind CodeKind
+		Pos  token.Position
 	}
 
-	NodeKind        int
-	OperatorKind    int
-	ConditionalNode struct {
-		Operator    OperatorKind
-		Var         values.Value
-		Left, Right *ConditionalNode
-		False       bool
-	}
 	Jump struct {
 		True  int
 		False int
@@ -37,26 +35,22 @@ func (i If) GetKind() CodeKind {
 	return i.Kind
 }
 
+func (i If) GetPos() token.Position {
+	return i.Pos
+}
+
 func (e Elif) GetKind() CodeKind {
 	return e.Kind
 }
 
+func (e Elif) GetPos() token.Position {
+	return e.Pos
+}
+
 func (e Else) GetKind() CodeKind {
 	return e.Kind
 }
 
-const (
-	AND OperatorKind = iota
-	OR
-	EQUAL
-	NOTEQUAL
-	STARTWITH
-	ENDWITH
-)
-
-var CompOperator = map[OperatorKind]bool{
-	EQUAL:     true,
-	NOTEQUAL:  true,
-	STARTWITH: true,
-	ENDWITH:   true,
+func (e Else) GetPos() token.Position {
+	return e.Pos
 }
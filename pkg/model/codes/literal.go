@@ -1,10 +1,17 @@
 package codes
 
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
 type Literal struct {
 	Kind    CodeKind
+	Pos     token.Position
 	Content string
 }
 
 func (l Literal) GetKind() CodeKind {
 	return l.Kind
 }
+
+func (l Literal) GetPos() token.Position {
+	return l.Pos
+}
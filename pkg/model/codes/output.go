@@ -1,12 +1,20 @@
 package codes
 
-import "github.com/ty-bnn/myriad/pkg/model/values"
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
 
 type Output struct {
 	Kind     CodeKind
+	Pos      token.Position
 	FilePath values.Value
 }
 
 func (o Output) GetKind() CodeKind {
 	return o.Kind
 }
+
+func (o Output) GetPos() token.Position {
+	return o.Pos
+}
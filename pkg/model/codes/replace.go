@@ -1,14 +1,20 @@
 package codes
 
 import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
 	"github.com/ty-bnn/myriad/pkg/model/values"
 )
 
 type Replace struct {
 	Kind  CodeKind
+	Pos   token.Position
 	Value values.Value
 }
 
 func (r Replace) GetKind() CodeKind {
 	return r.Kind
 }
+
+func (r Replace) GetPos() token.Position {
+	return r.Pos
+}
@@ -0,0 +1,20 @@
+package codes
+
+import (
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+type Return struct {
+	Kind  CodeKind
+	Pos   token.Position
+	Value values.Value
+}
+
+func (r Return) GetKind() CodeKind {
+	return r.Kind
+}
+
+func (r Return) GetPos() token.Position {
+	return r.Pos
+}
@@ -1,10 +1,20 @@
 package codes
 
+import "github.com/ty-bnn/myriad/pkg/model/token"
+
+// Sort sorts an array in place.
+// BySemver の場合は文字列ではなくバージョンの順に並べる
 type Sort struct {
-	Kind  CodeKind
-	Array string
+	Kind     CodeKind
+	Pos      token.Position
+	Array    string
+	BySemver bool
 }
 
 func (s Sort) GetKind() CodeKind {
 	return s.Kind
 }
+
+func (s Sort) GetPos() token.Position {
+	return s.Pos
+}
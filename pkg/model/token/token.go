@@ -12,6 +12,9 @@ const (
 	IN
 	KEYS
 	JSONUNMARSHAL
+	YAMLUNMARSHAL
+	CSVLOAD
+	DOTENVLOAD
 	STARTWITH
 	ENDWITH
 	TRIMLEFT
@@ -19,9 +22,21 @@ const (
 	SPLIT
 	APPEND
 	SORT
+	SORTSEMVER
+	UNIQUE
+	REVERSE
+	FILTER
+	RANGE
+	TRUE
+	FALSE
+	RETURN
+	DELETE
+	BREAK
+	CONTINUE
 	LPAREN
 	RPAREN
 	COMMA
+	COLON
 	LBRACE
 	RBRACE
 	LBRACKET
@@ -39,7 +54,17 @@ const (
 	DFBEGIN
 	DFEND
 	PLUS
+	MINUS
+	ASTERISK
+	SLASH
+	PERCENT
+	LESS
+	LESSEQUAL
+	GREATER
+	GREATEREQUAL
 	DOUBLELESS
+	ARROW
+	QUESTION
 	STRING
 	DFCOMMAND
 	DFARG
@@ -57,6 +82,9 @@ var ReservedKeywords = map[string]TokenKind{
 	"in":            IN,
 	"keys":          KEYS,
 	"JsonUnmarshal": JSONUNMARSHAL,
+	"YamlUnmarshal": YAMLUNMARSHAL,
+	"CsvLoad":       CSVLOAD,
+	"DotenvLoad":    DOTENVLOAD,
 	"startWith":     STARTWITH,
 	"endWith":       ENDWITH,
 	"trimLeft":      TRIMLEFT,
@@ -64,6 +92,17 @@ var ReservedKeywords = map[string]TokenKind{
 	"split":         SPLIT,
 	"append":        APPEND,
 	"sort":          SORT,
+	"sortSemver":    SORTSEMVER,
+	"unique":        UNIQUE,
+	"reverse":       REVERSE,
+	"filter":        FILTER,
+	"range":         RANGE,
+	"true":          TRUE,
+	"false":         FALSE,
+	"return":        RETURN,
+	"delete":        DELETE,
+	"break":         BREAK,
+	"continue":      CONTINUE,
 }
 
 var DockerfileCommands = map[string]bool{
@@ -91,4 +130,5 @@ type Token struct {
 	Content string
 	Kind    TokenKind
 	Line    int
+	Column  int
 }
@@ -0,0 +1,27 @@
+package values
+
+// Arithmetic represents a binary operation on numbers other than addition.
+// 加算は文字列の連結と共通のため AddString で表す
+type Arithmetic struct {
+	Kind     ValueKind
+	Operator ArithOperator
+	Left     Value
+	Right    Value
+}
+
+func (a Arithmetic) GetKind() ValueKind {
+	return a.Kind
+}
+
+func (a Arithmetic) GetName() string {
+	return ""
+}
+
+type ArithOperator int
+
+const (
+	SUB ArithOperator = iota
+	MUL
+	DIV
+	MOD
+)
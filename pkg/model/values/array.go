@@ -0,0 +1,18 @@
+package values
+
+// Array is an array from JsonUnmarshal whose elements are not all strings.
+// 要素はJsonUnmarshalと同じ形 (map, 配列, 文字列, 数値, 真偽値) で持つ
+type Array struct {
+	Kind  ValueKind
+	Value []interface{}
+	// Source はJSONファイルから読んだ場合のファイルのパス
+	Source string
+}
+
+func (a Array) GetKind() ValueKind {
+	return a.Kind
+}
+
+func (a Array) GetName() string {
+	return ""
+}
@@ -0,0 +1,28 @@
+package values
+
+// ArrayMethod represents a method call on an array which returns a new array.
+// e.g. pythons := variants.filter(v => v.startWith("python")) の右辺
+type ArrayMethod struct {
+	Kind   ValueKind
+	Target Value
+	Method MethodKind
+	// filter の引数名と条件
+	Param     string
+	Predicate ConditionalNode
+}
+
+func (a ArrayMethod) GetKind() ValueKind {
+	return a.Kind
+}
+
+func (a ArrayMethod) GetName() string {
+	return ""
+}
+
+type MethodKind int
+
+const (
+	UNIQUE MethodKind = iota
+	REVERSE
+	FILTER
+)
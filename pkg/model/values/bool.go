@@ -0,0 +1,14 @@
+package values
+
+type Bool struct {
+	Kind  ValueKind
+	Value bool
+}
+
+func (b Bool) GetKind() ValueKind {
+	return b.Kind
+}
+
+func (b Bool) GetName() string {
+	return ""
+}
@@ -0,0 +1,17 @@
+package values
+
+// BuiltinCall represents a call of a function provided by the language.
+// e.g. for (k in keys(data)) の keys(data)
+type BuiltinCall struct {
+	Kind ValueKind
+	Name string
+	Args []Value
+}
+
+func (b BuiltinCall) GetKind() ValueKind {
+	return b.Kind
+}
+
+func (b BuiltinCall) GetName() string {
+	return ""
+}
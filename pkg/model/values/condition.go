@@ -0,0 +1,55 @@
+package values
+
+type (
+	OperatorKind    int
+	ConditionalNode struct {
+		Operator    OperatorKind
+		Var         Value
+		Left, Right *ConditionalNode
+		False       bool
+	}
+)
+
+const (
+	AND OperatorKind = iota
+	OR
+	EQUAL
+	NOTEQUAL
+	STARTWITH
+	ENDWITH
+	LESS
+	LESSEQUAL
+	GREATER
+	GREATEREQUAL
+	// IN は左辺が右辺の配列に含まれるかを判定する
+	IN
+	// TRUTH はVarそのものを真偽値として評価する
+	TRUTH
+)
+
+var CompOperator = map[OperatorKind]bool{
+	EQUAL:        true,
+	NOTEQUAL:     true,
+	STARTWITH:    true,
+	ENDWITH:      true,
+	LESS:         true,
+	LESSEQUAL:    true,
+	GREATER:      true,
+	GREATEREQUAL: true,
+	IN:           true,
+}
+
+// Condition represents a conditional formula used as a value.
+// e.g. isAlpine := base.startWith("alpine") の右辺
+type Condition struct {
+	Kind ValueKind
+	Node ConditionalNode
+}
+
+func (c Condition) GetKind() ValueKind {
+	return c.Kind
+}
+
+func (c Condition) GetName() string {
+	return ""
+}
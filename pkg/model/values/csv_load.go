@@ -0,0 +1,16 @@
+package values
+
+// CsvLoad loads a CSV file as an array of maps keyed by the header line.
+type CsvLoad struct {
+	Kind ValueKind
+	Path Value
+	Dir  string
+}
+
+func (l CsvLoad) GetKind() ValueKind {
+	return l.Kind
+}
+
+func (l CsvLoad) GetName() string {
+	return ""
+}
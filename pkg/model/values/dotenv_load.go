@@ -0,0 +1,16 @@
+package values
+
+// DotenvLoad loads a .env file as a map.
+type DotenvLoad struct {
+	Kind ValueKind
+	Path Value
+	Dir  string
+}
+
+func (l DotenvLoad) GetKind() ValueKind {
+	return l.Kind
+}
+
+func (l DotenvLoad) GetName() string {
+	return ""
+}
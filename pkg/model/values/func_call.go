@@ -0,0 +1,17 @@
+package values
+
+// FuncCall represents a function call used as a value.
+// e.g. tag := imageTag(base) の右辺
+type FuncCall struct {
+	Kind ValueKind
+	Name string
+	Args []Value
+}
+
+func (f FuncCall) GetKind() ValueKind {
+	return f.Kind
+}
+
+func (f FuncCall) GetName() string {
+	return ""
+}
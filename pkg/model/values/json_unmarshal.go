@@ -0,0 +1,17 @@
+package values
+
+// JsonUnmarshal loads a JSON file when the Dockerfiles are generated.
+// Dir は呼び出したファイルのディレクトリで、相対パスはここから探す
+type JsonUnmarshal struct {
+	Kind ValueKind
+	Path Value
+	Dir  string
+}
+
+func (j JsonUnmarshal) GetKind() ValueKind {
+	return j.Kind
+}
+
+func (j JsonUnmarshal) GetName() string {
+	return ""
+}
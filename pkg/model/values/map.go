@@ -2,7 +2,7 @@ package values
 
 type Map struct {
 	Kind  ValueKind
-	Value map[string]interface{}
+	Value *Object
 }
 
 func (m Map) GetKind() ValueKind {
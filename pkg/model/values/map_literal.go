@@ -0,0 +1,17 @@
+package values
+
+// MapLiteral is a map written in the source, {"k": v}.
+// 値は実行時に評価してMapになる
+type MapLiteral struct {
+	Kind   ValueKind
+	Keys   []string
+	Values []Value
+}
+
+func (m MapLiteral) GetKind() ValueKind {
+	return m.Kind
+}
+
+func (m MapLiteral) GetName() string {
+	return ""
+}
@@ -4,6 +4,8 @@ type MapValue struct {
 	Kind ValueKind
 	Name string
 	Keys []Value
+	// Optional[i] が真のキーは無くてもよく、無い場合は null になる (data["18"]?["extra"])
+	Optional []bool
 }
 
 func (m MapValue) GetKind() ValueKind {
@@ -0,0 +1,17 @@
+package values
+
+// NamedArg represents an argument passed by name.
+// e.g. aptInstall(pkgs, clean: "false") の clean: "false"
+type NamedArg struct {
+	Kind  ValueKind
+	Name  string
+	Value Value
+}
+
+func (n NamedArg) GetKind() ValueKind {
+	return n.Kind
+}
+
+func (n NamedArg) GetName() string {
+	return ""
+}
@@ -0,0 +1,15 @@
+package values
+
+// Null represents a value which does not exist.
+// e.g. JSONの null や、data["18"]?["extra"] でキーが無かった場合の値
+type Null struct {
+	Kind ValueKind
+}
+
+func (n Null) GetKind() ValueKind {
+	return n.Kind
+}
+
+func (n Null) GetName() string {
+	return ""
+}
@@ -0,0 +1,14 @@
+package values
+
+type Number struct {
+	Kind  ValueKind
+	Value int
+}
+
+func (n Number) GetKind() ValueKind {
+	return n.Kind
+}
+
+func (n Number) GetName() string {
+	return ""
+}
@@ -0,0 +1,42 @@
+package values
+
+// Object is a JSON object that keeps the order of its keys.
+// キーはJSONファイルやmapに書かれた順に並び、新しいキーは末尾に追加する
+type Object struct {
+	Keys  []string
+	Items map[string]interface{}
+	// Source はJSONファイルから読んだ場合のファイルのパス
+	Source string
+}
+
+func NewObject() *Object {
+	return &Object{
+		Items: make(map[string]interface{}),
+	}
+}
+
+func (o *Object) Get(key string) (interface{}, bool) {
+	value, ok := o.Items[key]
+	return value, ok
+}
+
+func (o *Object) Set(key string, value interface{}) {
+	if _, ok := o.Items[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Items[key] = value
+}
+
+func (o *Object) Delete(key string) {
+	if _, ok := o.Items[key]; !ok {
+		return
+	}
+
+	delete(o.Items, key)
+	for i, k := range o.Keys {
+		if k == key {
+			o.Keys = append(o.Keys[:i:i], o.Keys[i+1:]...)
+			break
+		}
+	}
+}
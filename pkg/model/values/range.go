@@ -0,0 +1,16 @@
+package values
+
+// Range represents integers from Start to End, excluding End.
+type Range struct {
+	Kind  ValueKind
+	Start Value
+	End   Value
+}
+
+func (r Range) GetKind() ValueKind {
+	return r.Kind
+}
+
+func (r Range) GetName() string {
+	return ""
+}
@@ -0,0 +1,18 @@
+package values
+
+// Slice represents a part of an array or a string.
+// e.g. a[1:3] (Start, End が nil の場合は先頭, 末尾)
+type Slice struct {
+	Kind   ValueKind
+	Target Value
+	Start  Value
+	End    Value
+}
+
+func (s Slice) GetKind() ValueKind {
+	return s.Kind
+}
+
+func (s Slice) GetName() string {
+	return ""
+}
@@ -18,4 +18,21 @@ const (
 	ADDSTRING
 	TRIMSTRING
 	SPLITSTRING
+	NUMBER
+	ARITHMETIC
+	RANGE
+	BOOL
+	CONDITION
+	FUNCCALL
+	NAMEDARG
+	MAPLITERAL
+	ARRAY
+	BUILTINCALL
+	JSONUNMARSHAL
+	YAMLUNMARSHAL
+	CSVLOAD
+	DOTENVLOAD
+	ARRAYMETHOD
+	SLICE
+	NULL
 )
@@ -0,0 +1,18 @@
+package values
+
+// YamlUnmarshal loads a YAML file when the Dockerfiles are generated.
+// Document はファイル中の何番目のドキュメントを読むか (省略した場合は最初のドキュメント)
+type YamlUnmarshal struct {
+	Kind     ValueKind
+	Path     Value
+	Document Value
+	Dir      string
+}
+
+func (y YamlUnmarshal) GetKind() ValueKind {
+	return y.Kind
+}
+
+func (y YamlUnmarshal) GetName() string {
+	return ""
+}
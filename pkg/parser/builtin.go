@@ -0,0 +1,29 @@
+package parser
+
+// builtins は組み込み関数の名前と、受け取る引数の数の範囲 (最小, 最大)
+// 最大が anyArgs の場合は引数の数に上限がない
+// 同じ名前の関数を定義、インポートした場合はそちらを呼び出す
+var builtins = map[string][2]int{
+	"keys":          {1, 1},
+	"len":           {1, 1},
+	"has":           {2, 2},
+	"default":       {2, 2},
+	"env":           {1, 2},
+	"replace":       {3, 3},
+	"toUpper":       {1, 1},
+	"toLower":       {1, 1},
+	"toNumber":      {1, 1},
+	"contains":      {2, 2},
+	"join":          {2, 2},
+	"trimPrefix":    {2, 2},
+	"trimSuffix":    {2, 2},
+	"padLeft":       {2, 3},
+	"format":        {1, anyArgs},
+	"matches":       {2, 2},
+	"capture":       {2, 2},
+	"semverCompare": {2, 2},
+	"semverMajor":   {1, 1},
+	"semverMinor":   {1, 1},
+}
+
+const anyArgs = -1
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/tokenizer"
+	"github.com/ty-bnn/myriad/pkg/utils"
+)
+
+// loader keeps the files parsed in a compilation.
+// 同じファイルを複数のファイルからインポートしても、解析は一度だけ行う
+type loader struct {
+	modules map[string]*Parser
+	// 解析中のファイルの並び (インポートの連鎖)
+	stack []string
+}
+
+func newLoader() *loader {
+	return &loader{
+		modules: make(map[string]*Parser),
+	}
+}
+
+// moduleKey returns the key that identifies the file in a compilation.
+func moduleKey(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(filePath)
+}
+
+// cycle returns the import chain if importing the file makes an import cycle.
+// 連鎖は最初に解析したファイルから、再びインポートしたファイルまでを並べる
+func (l *loader) cycle(filePath string) (string, bool) {
+	key := moduleKey(filePath)
+	for _, importing := range l.stack {
+		if moduleKey(importing) == key {
+			chain := append(append([]string{}, l.stack...), filePath)
+			return strings.Join(chain, " -> "), true
+		}
+	}
+
+	return "", false
+}
+
+func (l *loader) enter(filePath string) {
+	l.stack = append(l.stack, filePath)
+}
+
+func (l *loader) leave() {
+	l.stack = l.stack[:len(l.stack)-1]
+}
+
+// loadModule parses an imported file, or returns the parser of the file if it is already parsed.
+func (p *Parser) loadModule(filePath string, pos token.Position) (*Parser, error) {
+	key := moduleKey(filePath)
+	if module, ok := p.loader.modules[key]; ok {
+		return module, nil
+	}
+
+	if chain, ok := p.loader.cycle(filePath); ok {
+		return nil, p.errorfAt(pos, "semantic error: import cycle: %s", chain)
+	}
+
+	lines, err := utils.ReadLinesFromFile(filePath)
+	if err != nil {
+		return nil, p.errorfAt(pos, "%s", err)
+	}
+
+	t := tokenizer.NewTokenizer(lines, filePath)
+	err = t.Tokenize()
+	if err != nil {
+		return nil, err
+	}
+
+	newP := NewParser(t.Tokens, filePath)
+	newP.prefix = key + "#"
+	newP.SearchPath = p.SearchPath
+	newP.loader = p.loader
+	err = newP.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	p.loader.modules[key] = newP
+
+	return newP, nil
+}
@@ -1,92 +1,130 @@
 package parser
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 
 	"github.com/ty-bnn/myriad/pkg/model/codes"
 	"github.com/ty-bnn/myriad/pkg/model/token"
 	"github.com/ty-bnn/myriad/pkg/model/values"
-	"github.com/ty-bnn/myriad/pkg/tokenizer"
-	"github.com/ty-bnn/myriad/pkg/utils"
 )
 
 func (p *Parser) Parse() error {
 	fmt.Printf("Parsing %s ...\n", p.filePath)
 
-	err := p.program()
-	if err != nil {
-		return err
+	p.loader.enter(p.filePath)
+	defer p.loader.leave()
+
+	p.program()
+	if len(p.errs) > 0 {
+		return p.errs
 	}
 
 	fmt.Printf("Parse %s Done.\n", p.filePath)
 	return nil
 }
 
-func (p *Parser) program() error {
-	var err error
+// エラーが発生しても関数の境界から解析を再開し、全てのエラーを集める
+func (p *Parser) program() {
 	// { 関数インポート文 }
-	for {
-		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IMPORT {
-			break
-		}
-
-		err = p.importFunc()
+	for p.tokenIs(token.IMPORT, 0) {
+		start := p.index
+		err := p.importFunc()
 		if err != nil {
-			return err
+			p.addError(err)
+			p.syncFunction(start)
+			p.importFailed = true
 		}
 	}
 
-	// { 関数 }
-	for {
-		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-			break
-		}
+	p.declareFunctions()
 
-		err = p.function()
+	// { 関数 }
+	for p.tokenIs(token.IDENTIFIER, 0) {
+		start := p.index
+		err := p.function()
 		if err != nil {
-			return err
+			p.addError(err)
+			p.syncFunction(start)
 		}
 	}
 
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.MAIN {
-		return nil
-	}
-
 	// メイン部
-	err = p.mainFunction()
-	if err != nil {
-		return err
+	if p.tokenIs(token.MAIN, 0) {
+		start := p.index
+		err := p.mainFunction()
+		if err != nil {
+			p.addError(err)
+			p.syncFunction(start)
+		}
 	}
 
-	return nil
+	if p.index < len(p.tokens) {
+		p.addError(p.errorf("syntax error: unexpected token '%s'", p.tokens[p.index].Content))
+	}
 }
 
 // 関数インポート文
+// import { a, b } from "file" は指定した関数だけを、import ns "file" は ns.a の形で、
+// import a from "file" はファイルの全ての関数を取り込む
 func (p *Parser) importFunc() error {
 	var err error
+	pos := p.pos()
 	// "import"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IMPORT {
-		return errors.New(fmt.Sprintf("syntax error: cannot find 'import'"))
+		return p.errorf("syntax error: cannot find 'import'")
 	}
 
 	p.index++
 
-	// 関数名
-	_, err = p.functionName()
-	if err != nil {
-		return err
+	var (
+		selected  []string
+		selPoses  []token.Position
+		namespace string
+	)
+	if p.tokenIs(token.LBRACE, 0) {
+		// 関数名の並び
+		p.index++
+		for {
+			selPoses = append(selPoses, p.pos())
+			funcName, err := p.functionName()
+			if err != nil {
+				return err
+			}
+			selected = append(selected, funcName)
+
+			if !p.tokenIs(token.COMMA, 0) {
+				break
+			}
+			p.index++
+		}
+
+		if !p.tokenIs(token.RBRACE, 0) {
+			return p.errorf("syntax error: cannot find '}'")
+		}
+		p.index++
+	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.STRING, 1) {
+		// 名前空間
+		namespace = p.tokens[p.index].Content
+		p.index++
+	} else {
+		// 関数名
+		_, err = p.functionName()
+		if err != nil {
+			return err
+		}
 	}
 
 	// "from"
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.FROM {
-		return errors.New(fmt.Sprintf("syntax error: cannot find 'from'"))
-	}
+	if namespace == "" {
+		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.FROM {
+			return p.errorf("syntax error: cannot find 'from'")
+		}
 
-	p.index++
+		p.index++
+	}
 
 	// ファイル名
 	filePath, err := p.fileName()
@@ -94,33 +132,47 @@ func (p *Parser) importFunc() error {
 		return err
 	}
 
-	if p.isCompiled(filePath) {
-		return nil
-	}
-
-	lines, err := utils.ReadLinesFromFile(filePath)
+	filePath, err = p.resolvePath(filePath)
 	if err != nil {
-		return err
+		return p.errorfAt(pos, "%s", err)
 	}
 
-	t := tokenizer.NewTokenizer(lines, filePath)
-	err = t.Tokenize()
+	newP, err := p.loadModule(filePath, pos)
 	if err != nil {
 		return err
 	}
 
-	newP := NewParser(t.Tokens, filePath)
-	err = newP.Parse()
-	if err != nil {
-		return err
+	p.addFuncCodes(newP.FuncToCodes)
+
+	if selected != nil {
+		for i, name := range selected {
+			funcName, ok := newP.scope[name]
+			if !ok {
+				return p.errorfAt(selPoses[i], "semantic error: %s is not defined in %s", name, filePath)
+			}
+			if err := p.declare(name, funcName, selPoses[i]); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	err = p.addFuncCodes(newP.FuncToCodes)
-	if err != nil {
-		return err
+	// 衝突した場合に報告する名前が毎回同じになるよう、名前の順に宣言する
+	names := make([]string, 0, len(newP.scope))
+	for name := range newP.scope {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	p.compiledFiles = append(p.compiledFiles, filePath)
+	for _, name := range names {
+		funcName := newP.scope[name]
+		if namespace != "" {
+			name = namespace + "." + name
+		}
+		if err := p.declare(name, funcName, pos); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -128,7 +180,7 @@ func (p *Parser) importFunc() error {
 // ファイル名
 func (p *Parser) fileName() (string, error) {
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.STRING {
-		return "", errors.New(fmt.Sprintf("syntax error: cannot find an identifier"))
+		return "", p.errorf("syntax error: cannot find an identifier")
 	}
 
 	fileName := p.tokens[p.index].Content
@@ -144,13 +196,15 @@ func (p *Parser) function() error {
 	var err error
 
 	// 関数名
-	funcName, err := p.functionName()
+	pos := p.pos()
+	name, err := p.functionName()
 	if err != nil {
 		return err
 	}
 
+	funcName := p.prefix + name
 	if _, has := p.FuncToCodes[funcName]; has {
-		return errors.New(fmt.Sprintf("semantic error: %s is already declared", funcName))
+		return p.errorfAt(pos, "semantic error: %s is already declared", name)
 	}
 
 	// 引数宣言部
@@ -177,12 +231,12 @@ func (p *Parser) mainFunction() error {
 
 	// "main"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.MAIN {
-		return errors.New(fmt.Sprintf("syntax error: cannot find 'main'"))
+		return p.errorf("syntax error: cannot find 'main'")
 	}
 
-	funcName := "main"
+	funcName := p.prefix + "main"
 	if _, has := p.FuncToCodes[funcName]; has {
-		return errors.New(fmt.Sprintf("semantic error: %s is already declared", funcName))
+		return p.errorf("semantic error: %s is already declared", funcName)
 	}
 
 	p.index++
@@ -212,14 +266,14 @@ func (p *Parser) argumentDeclaration() ([]codes.Code, error) {
 
 	// "("
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 
 	p.index++
 
 	// 引数群
 	if p.index < len(p.tokens) && p.tokens[p.index].Kind == token.IDENTIFIER {
-		argCodes, err = p.arguments()
+		argCodes, err = p.parameters()
 		if err != nil {
 			return nil, err
 		}
@@ -227,7 +281,7 @@ func (p *Parser) argumentDeclaration() ([]codes.Code, error) {
 
 	// ")"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 
 	p.index++
@@ -236,23 +290,16 @@ func (p *Parser) argumentDeclaration() ([]codes.Code, error) {
 }
 
 // 引数群
-func (p *Parser) arguments() ([]codes.Code, error) {
+func (p *Parser) parameters() ([]codes.Code, error) {
 	var argCodes []codes.Code
-	var err error
 
-	// 変数名
-	argName, err := p.variableName()
+	// 引数
+	argCode, err := p.parameter()
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Valueをnilのままにしたい
-	defCode := codes.Define{
-		Kind: codes.DEFINE,
-		Key:  argName,
-	}
-
-	argCodes = append(argCodes, defCode)
+	argCodes = append(argCodes, argCode)
 
 	for {
 		// ","
@@ -262,40 +309,68 @@ func (p *Parser) arguments() ([]codes.Code, error) {
 
 		p.index++
 
-		// 変数名
-		argName, err = p.variableName()
+		// 引数
+		argCode, err = p.parameter()
 		if err != nil {
 			return nil, err
 		}
 
-		// TODO: Valueをnilのままにしたい
-		defCode = codes.Define{
-			Kind: codes.DEFINE,
-			Key:  argName,
+		for _, c := range argCodes {
+			if c.(codes.Argument).Key == argCode.Key {
+				return nil, p.errorfAt(argCode.Pos, "semantic error: %s is already declared", argCode.Key)
+			}
 		}
 
-		argCodes = append(argCodes, defCode)
+		argCodes = append(argCodes, argCode)
 	}
 
 	return argCodes, nil
 }
 
+// 引数
+func (p *Parser) parameter() (codes.Argument, error) {
+	// 変数名
+	pos := p.pos()
+	argName, err := p.variableName()
+	if err != nil {
+		return codes.Argument{}, err
+	}
+
+	argCode := codes.Argument{Kind: codes.ARGUMENT, Pos: pos, Key: argName}
+
+	// "=" 既定値
+	if p.tokenIs(token.ASSIGN, 0) {
+		p.index++
+
+		value, err := p.assignValue()
+		if err != nil {
+			return codes.Argument{}, err
+		}
+		argCode.Default = value
+	}
+
+	return argCode, nil
+}
+
 // main記述ブロック群
 func (p *Parser) descriptionBlockGroup() ([]codes.Code, error) {
 	var descCodes []codes.Code
 
 	// "{"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LBRACE {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '{'"))
+		return nil, p.errorf("syntax error: cannot find '{'")
 	}
 
 	p.index++
 
-	for p.tokenIs(token.DFBEGIN, 0) || p.tokenIs(token.DFARG, 0) || p.tokenIs(token.IDENTIFIER, 0) || p.tokenIs(token.IF, 0) || p.tokenIs(token.FOR, 0) {
+	for p.isStatementStart() {
+		start := p.index
 		if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.DOUBLELESS, 1) {
 			outCodes, err := p.outputBlock()
 			if err != nil {
-				return nil, err
+				p.addError(err)
+				p.syncStatement(start)
+				continue
 			}
 			descCodes = append(descCodes, outCodes...)
 			continue
@@ -303,7 +378,9 @@ func (p *Parser) descriptionBlockGroup() ([]codes.Code, error) {
 
 		descBCodes, err := p.descriptionBlock()
 		if err != nil {
-			return nil, err
+			p.addError(err)
+			p.syncStatement(start)
+			continue
 		}
 
 		descCodes = append(descCodes, descBCodes...)
@@ -311,7 +388,7 @@ func (p *Parser) descriptionBlockGroup() ([]codes.Code, error) {
 
 	// "}"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RBRACE {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '}'"))
+		return nil, p.errorf("syntax error: cannot find '}'")
 	}
 
 	p.index++
@@ -323,12 +400,14 @@ func (p *Parser) descriptionBlockGroup() ([]codes.Code, error) {
 func (p *Parser) outputBlock() ([]codes.Code, error) {
 	var codeBlock []codes.Code
 
+	pos := p.pos()
 	vName, err := p.variableName()
 	if err != nil {
 		return nil, err
 	}
 	codeBlock = append(codeBlock, codes.Output{
 		Kind: codes.OUTPUT,
+		Pos:  pos,
 		FilePath: values.Ident{
 			Kind: values.IDENT,
 			Name: vName,
@@ -337,7 +416,7 @@ func (p *Parser) outputBlock() ([]codes.Code, error) {
 
 	// "<<"
 	if !p.tokenIs(token.DOUBLELESS, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '<<'"))
+		return nil, p.errorf("syntax error: cannot find '<<'")
 	}
 	p.index++
 
@@ -364,7 +443,7 @@ func (p *Parser) descriptionBlock() ([]codes.Code, error) {
 		}
 
 		return dockerCodes, nil
-	} else if p.index+1 < len(p.tokens) && p.tokens[p.index].Kind == token.IDENTIFIER && p.tokens[p.index+1].Kind == token.LPAREN {
+	} else if p.isFunctionCall() {
 		// 関数呼び出し文
 		funcCode, err := p.functionCall()
 		if err != nil {
@@ -410,15 +489,50 @@ func (p *Parser) descriptionBlock() ([]codes.Code, error) {
 			return nil, err
 		}
 		return []codes.Code{appendCode}, nil
-	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.DOT, 1) && p.tokenIs(token.SORT, 2) {
+	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.DOT, 1) && (p.tokenIs(token.SORT, 2) || p.tokenIs(token.SORTSEMVER, 2)) {
 		sortCode, err := p.sortArray()
 		if err != nil {
 			return nil, err
 		}
 		return []codes.Code{sortCode}, nil
+	} else if p.isArrayMethod() {
+		// 配列操作文 (変数を操作後の配列で置き換える)
+		assignCode, err := p.arrayMethodStatement()
+		if err != nil {
+			return nil, err
+		}
+		return []codes.Code{assignCode}, nil
+	} else if p.tokenIs(token.RETURN, 0) {
+		// return文
+		retCode, err := p.returnStatement()
+		if err != nil {
+			return nil, err
+		}
+		return []codes.Code{retCode}, nil
+	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.LBRACKET, 1) {
+		// map要素代入文
+		assignCode, err := p.assignElement()
+		if err != nil {
+			return nil, err
+		}
+		return []codes.Code{assignCode}, nil
+	} else if p.tokenIs(token.BREAK, 0) || p.tokenIs(token.CONTINUE, 0) {
+		// break文, continue文
+		loopCode, err := p.loopControl()
+		if err != nil {
+			return nil, err
+		}
+		return []codes.Code{loopCode}, nil
+	} else if p.tokenIs(token.DELETE, 0) {
+		// delete文
+		deleteCode, err := p.deleteStatement()
+		if err != nil {
+			return nil, err
+		}
+		return []codes.Code{deleteCode}, nil
 	}
 
-	return nil, errors.New(fmt.Sprintf("syntax error: cannot find a description block"))
+	return nil, p.errorf("syntax error: cannot find a description block")
 }
 
 // Dfileブロック
@@ -427,7 +541,7 @@ func (p *Parser) dockerfileBlock() ([]codes.Code, error) {
 
 	// {{-
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.DFBEGIN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '{{-'"))
+		return nil, p.errorf("syntax error: cannot find '{{-'")
 	}
 	p.index++
 
@@ -438,14 +552,16 @@ func (p *Parser) dockerfileBlock() ([]codes.Code, error) {
 
 		dockerCodes, err := p.dockerFile()
 		if err != nil {
-			return nil, err
+			p.addError(err)
+			p.syncDockerfile()
+			continue
 		}
 		dockerBlockCodes = append(dockerBlockCodes, dockerCodes...)
 	}
 
 	// -}}
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.DFEND {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '-}}'"))
+		return nil, p.errorf("syntax error: cannot find '-}}'")
 	}
 	p.index++
 
@@ -459,7 +575,7 @@ func (p *Parser) dockerFile() ([]codes.Code, error) {
 
 	// Df命令
 	if p.tokens[p.index].Kind == token.DFCOMMAND {
-		cmdCode := codes.Command{Kind: codes.COMMAND, Content: p.tokens[p.index].Content}
+		cmdCode := codes.Command{Kind: codes.COMMAND, Pos: p.pos(), Content: p.tokens[p.index].Content}
 		dockerCodes = append(dockerCodes, cmdCode)
 		p.index++
 	}
@@ -507,7 +623,7 @@ func (p *Parser) dfArgs() ([]codes.Code, error) {
 func (p *Parser) dfArg() (codes.Code, error) {
 	if p.index < len(p.tokens) && p.tokens[p.index].Kind == token.DFARG {
 		// 生のDf引数
-		rowCode := codes.Literal{Kind: codes.LITERAL, Content: p.tokens[p.index].Content}
+		rowCode := codes.Literal{Kind: codes.LITERAL, Pos: p.pos(), Content: p.tokens[p.index].Content}
 		p.index++
 
 		return rowCode, nil
@@ -521,26 +637,27 @@ func (p *Parser) dfArg() (codes.Code, error) {
 		return repCode, nil
 	}
 
-	return nil, errors.New(fmt.Sprintf("syntax error: cannot find Df argument"))
+	return nil, p.errorf("syntax error: cannot find Df argument")
 }
 
 // 置換式
 func (p *Parser) replaceFormula() (codes.Code, error) {
 	// {{
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LDOUBLEBRA {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '{{'"))
+		return nil, p.errorf("syntax error: cannot find '{{'")
 	}
+	pos := p.pos()
 	p.index++
 
 	target, err := p.singleAssignFormula()
 	if err != nil {
 		return nil, err
 	}
-	repCode := codes.Replace{Kind: codes.REPLACE, Value: target}
+	repCode := codes.Replace{Kind: codes.REPLACE, Pos: pos, Value: target}
 
 	// }}
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RDOUBLEBRA {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '}}'"))
+		return nil, p.errorf("syntax error: cannot find '}}'")
 	}
 
 	p.index++
@@ -554,21 +671,24 @@ func (p *Parser) functionCall() (codes.Code, error) {
 	var err error
 
 	// 関数名
-	funcName, err := p.functionName()
+	pos := p.pos()
+	funcName, isBuiltin, err := p.calleeName()
 	if err != nil {
 		return nil, err
 	}
+	if isBuiltin {
+		return nil, p.errorfAt(pos, "semantic error: %s returns a value and cannot be called as a statement", funcName)
+	}
 
 	// "("
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 
 	p.index++
 
 	// 代入値の並び
-	if p.index < len(p.tokens) && (p.tokens[p.index].Kind == token.STRING || p.tokens[p.index].Kind == token.IDENTIFIER ||
-		p.tokens[p.index].Kind == token.LBRACE || p.tokens[p.index].Kind == token.JSONUNMARSHAL) {
+	if p.index < len(p.tokens) && p.tokens[p.index].Kind != token.RPAREN {
 		args, err = p.rowOfAssignValues()
 		if err != nil {
 			return nil, err
@@ -577,42 +697,243 @@ func (p *Parser) functionCall() (codes.Code, error) {
 
 	// ")"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 
-	cpCode := codes.CallProc{Kind: codes.CALLPROC, ProcName: funcName, Args: args}
+	cpCode := codes.CallProc{Kind: codes.CALLPROC, Pos: pos, ProcName: funcName, Args: args}
 
 	p.index++
 
 	return cpCode, nil
 }
 
-// 代入値の並び
-func (p *Parser) rowOfAssignValues() ([]values.Value, error) {
-	var assignValues []values.Value
+// 関数呼び出し式
+func (p *Parser) functionCallValue() (values.Value, error) {
+	var args []values.Value
 	var err error
+
+	// 関数名
+	pos := p.pos()
+	funcName, isBuiltin, err := p.calleeName()
+	if err != nil {
+		return nil, err
+	}
+
+	// "("
+	if !p.tokenIs(token.LPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find '('")
+	}
+	p.index++
+
+	// 代入値の並び
+	if p.index < len(p.tokens) && !p.tokenIs(token.RPAREN, 0) {
+		args, err = p.rowOfAssignValues()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// ")"
+	if !p.tokenIs(token.RPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find ')'")
+	}
+	p.index++
+
+	if isBuiltin {
+		return p.builtinCall(pos, funcName, args)
+	}
+
+	return values.FuncCall{Kind: values.FUNCCALL, Name: funcName, Args: args}, nil
+}
+
+// 組み込み関数の呼び出し
+// 引数の数は解析時に確かめる
+func (p *Parser) builtinCall(pos token.Position, name string, args []values.Value) (values.Value, error) {
+	for _, arg := range args {
+		if arg.GetKind() == values.NAMEDARG {
+			return nil, p.errorfAt(pos, "semantic error: %s does not take named args", name)
+		}
+	}
+
+	arity := builtins[name]
+	if len(args) < arity[0] || (arity[1] != anyArgs && arity[1] < len(args)) {
+		if arity[1] == anyArgs {
+			return nil, p.errorfAt(pos, "semantic error: %s takes at least %d args but got %d", name, arity[0], len(args))
+		}
+		if arity[0] == arity[1] {
+			return nil, p.errorfAt(pos, "semantic error: %s takes %d args but got %d", name, arity[0], len(args))
+		}
+		return nil, p.errorfAt(pos, "semantic error: %s takes %d to %d args but got %d", name, arity[0], arity[1], len(args))
+	}
+
+	return values.BuiltinCall{Kind: values.BUILTINCALL, Name: name, Args: args}, nil
+}
+
+// return文
+func (p *Parser) returnStatement() (codes.Code, error) {
+	// "return"
+	if !p.tokenIs(token.RETURN, 0) {
+		return nil, p.errorf("syntax error: cannot find 'return'")
+	}
+	pos := p.pos()
+	p.index++
+
+	// 戻り値のないreturn
+	if p.tokenIs(token.RBRACE, 0) {
+		return codes.Return{Kind: codes.RETURN, Pos: pos}, nil
+	}
+
+	value, err := p.assignValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return codes.Return{Kind: codes.RETURN, Pos: pos, Value: value}, nil
+}
+
+// map要素代入文
+func (p *Parser) assignElement() (codes.Code, error) {
+	pos := p.pos()
+
+	// map要素
+	target, err := p.mapValue()
+	if err != nil {
+		return nil, err
+	}
+	if target.Optional != nil {
+		return nil, p.errorfAt(pos, "syntax error: '?' cannot be used to change a map")
+	}
+
+	// "="
+	if !p.tokenIs(token.ASSIGN, 0) {
+		return nil, p.errorf("syntax error: cannot find '='")
+	}
+	p.index++
+
 	// 代入値
 	value, err := p.assignValue()
 	if err != nil {
 		return nil, err
 	}
-	assignValues = append(assignValues, value)
+
+	return codes.AssignElement{Kind: codes.ASSIGNELEMENT, Pos: pos, Target: target, Value: value}, nil
+}
+
+// break文, continue文
+func (p *Parser) loopControl() (codes.Code, error) {
+	pos := p.pos()
+	if p.tokenIs(token.BREAK, 0) {
+		p.index++
+		if p.loopDepth == 0 {
+			return nil, p.errorfAt(pos, "semantic error: break is not in a loop")
+		}
+		return codes.Break{Kind: codes.BREAK, Pos: pos}, nil
+	}
+
+	if !p.tokenIs(token.CONTINUE, 0) {
+		return nil, p.errorf("syntax error: cannot find 'break' or 'continue'")
+	}
+	p.index++
+	if p.loopDepth == 0 {
+		return nil, p.errorfAt(pos, "semantic error: continue is not in a loop")
+	}
+
+	return codes.Continue{Kind: codes.CONTINUE, Pos: pos}, nil
+}
+
+// delete文
+func (p *Parser) deleteStatement() (codes.Code, error) {
+	// "delete"
+	if !p.tokenIs(token.DELETE, 0) {
+		return nil, p.errorf("syntax error: cannot find 'delete'")
+	}
+	pos := p.pos()
+	p.index++
+
+	// "("
+	if !p.tokenIs(token.LPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find '('")
+	}
+	p.index++
+
+	// map
+	target, err := p.mapValue()
+	if err != nil {
+		return nil, err
+	}
+	if target.Optional != nil {
+		return nil, p.errorfAt(pos, "syntax error: '?' cannot be used to change a map")
+	}
+
+	// ","
+	if !p.tokenIs(token.COMMA, 0) {
+		return nil, p.errorf("syntax error: cannot find ','")
+	}
+	p.index++
+
+	// キー
+	key, err := p.singleAssignFormula()
+	if err != nil {
+		return nil, err
+	}
+
+	// ")"
+	if !p.tokenIs(token.RPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find ')'")
+	}
+	p.index++
+
+	return codes.Delete{Kind: codes.DELETE, Pos: pos, Target: target, Key: key}, nil
+}
+
+// 代入値の並び
+// 名前付き引数の後に位置引数は置けない
+func (p *Parser) rowOfAssignValues() ([]values.Value, error) {
+	var assignValues []values.Value
+	var hasNamed bool
 
 	for {
+		// 実引数
+		pos := p.pos()
+		value, err := p.callArgument()
+		if err != nil {
+			return assignValues, err
+		}
+
+		if value.GetKind() == values.NAMEDARG {
+			hasNamed = true
+		} else if hasNamed {
+			return assignValues, p.errorfAt(pos, "syntax error: positional argument follows named argument")
+		}
+		assignValues = append(assignValues, value)
+
 		// ","
 		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.COMMA {
 			break
 		}
 		p.index++
-		// 代入値
+	}
+
+	return assignValues, nil
+}
+
+// 実引数
+func (p *Parser) callArgument() (values.Value, error) {
+	if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.COLON, 1) {
+		// 名前付き引数
+		name := p.tokens[p.index].Content
+		p.index += 2
+
 		value, err := p.assignValue()
 		if err != nil {
-			return assignValues, err
+			return nil, err
 		}
-		assignValues = append(assignValues, value)
+
+		return values.NamedArg{Kind: values.NAMEDARG, Name: name, Value: value}, nil
 	}
 
-	return assignValues, nil
+	// 代入値
+	return p.assignValue()
 }
 
 // ifブロック
@@ -626,7 +947,7 @@ func (p *Parser) ifBlock() ([]codes.Code, error) {
 	var jumps []Jump
 
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IF {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'if'"))
+		return nil, p.errorf("syntax error: cannot find 'if'")
 	}
 
 	ifCodes, err := p.ifSection()
@@ -681,7 +1002,7 @@ func (p *Parser) ifBlock() ([]codes.Code, error) {
 }
 
 // 条件判定式
-func (p *Parser) conditionalFormula() (*codes.ConditionalNode, error) {
+func (p *Parser) conditionalFormula() (*values.ConditionalNode, error) {
 	// 項
 	root, err := p.term()
 	if err != nil {
@@ -698,8 +1019,8 @@ func (p *Parser) conditionalFormula() (*codes.ConditionalNode, error) {
 			return nil, err
 		}
 
-		newRoot := codes.ConditionalNode{
-			Operator: codes.OR,
+		newRoot := values.ConditionalNode{
+			Operator: values.OR,
 			Left:     root,
 			Right:    rNode,
 		}
@@ -711,7 +1032,7 @@ func (p *Parser) conditionalFormula() (*codes.ConditionalNode, error) {
 }
 
 // 項
-func (p *Parser) term() (*codes.ConditionalNode, error) {
+func (p *Parser) term() (*values.ConditionalNode, error) {
 	// 因子
 	root, err := p.factor()
 	if err != nil {
@@ -728,8 +1049,8 @@ func (p *Parser) term() (*codes.ConditionalNode, error) {
 			return nil, err
 		}
 
-		newRoot := codes.ConditionalNode{
-			Operator: codes.AND,
+		newRoot := values.ConditionalNode{
+			Operator: values.AND,
 			Left:     root,
 			Right:    rNode,
 		}
@@ -741,27 +1062,30 @@ func (p *Parser) term() (*codes.ConditionalNode, error) {
 }
 
 // 因子
-func (p *Parser) factor() (*codes.ConditionalNode, error) {
-	if p.index < len(p.tokens) && p.tokens[p.index].Kind == token.LPAREN {
-		// (
+func (p *Parser) factor() (*values.ConditionalNode, error) {
+	stackIndex := p.index
+	if p.tokenIs(token.NOT, 0) {
+		// !
 		p.index++
 
-		// 条件判定式
-		condFml, err := p.conditionalFormula()
-		if err != nil {
-			return nil, err
+		// 因子
+		node, err := p.factor()
+		if err == nil {
+			node.False = !node.False
+			return node, nil
 		}
+		p.index = stackIndex
+	}
 
-		// )
-		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-			return nil, errors.New(fmt.Sprintf("syntax error: cannot find )"))
+	if p.tokenIs(token.LPAREN, 0) {
+		condFml, err := p.parenConditionalFormula()
+		if err == nil && !p.isValueOperator() {
+			return condFml, nil
 		}
-		p.index++
-
-		return condFml, nil
+		// 括弧で始まる算術式として読み直す
+		p.index = stackIndex
 	}
 
-	stackIndex := p.index
 	compFml, err := p.compFormula()
 	if err == nil {
 		return compFml, nil
@@ -769,36 +1093,75 @@ func (p *Parser) factor() (*codes.ConditionalNode, error) {
 
 	p.index = stackIndex
 	compFml, err = p.analyzeStringFormula()
+	if err == nil {
+		return compFml, nil
+	}
+
+	p.index = stackIndex
+	compFml, err = p.truthFormula()
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find compFormula or analyzeStringFormula"))
+		return nil, p.errorf("syntax error: cannot find compFormula, analyzeStringFormula or boolean value")
 	}
 	return compFml, nil
 }
 
-// 比較式
-func (p *Parser) compFormula() (*codes.ConditionalNode, error) {
-	left, err := p.singleAssignFormula()
+// 真偽値式
+func (p *Parser) truthFormula() (*values.ConditionalNode, error) {
+	value, err := p.singleAssignFormula()
 	if err != nil {
 		return nil, err
 	}
-	lNode := codes.ConditionalNode{Var: left}
 
-	op, err := p.conditionalOperator()
-	if err != nil {
-		return nil, err
+	return &values.ConditionalNode{Operator: values.TRUTH, Var: value}, nil
+}
+
+// 括弧付き条件判定式
+func (p *Parser) parenConditionalFormula() (*values.ConditionalNode, error) {
+	// (
+	if !p.tokenIs(token.LPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find (")
+	}
+	p.index++
+
+	// 条件判定式
+	condFml, err := p.conditionalFormula()
+	if err != nil {
+		return nil, err
+	}
+
+	// )
+	if !p.tokenIs(token.RPAREN, 0) {
+		return nil, p.errorf("syntax error: cannot find )")
+	}
+	p.index++
+
+	return condFml, nil
+}
+
+// 比較式
+func (p *Parser) compFormula() (*values.ConditionalNode, error) {
+	left, err := p.singleAssignFormula()
+	if err != nil {
+		return nil, err
+	}
+	lNode := values.ConditionalNode{Var: left}
+
+	op, err := p.conditionalOperator()
+	if err != nil {
+		return nil, err
 	}
 
 	right, err := p.singleAssignFormula()
 	if err != nil {
 		return nil, err
 	}
-	rNode := codes.ConditionalNode{Var: right}
+	rNode := values.ConditionalNode{Var: right}
 
-	return &codes.ConditionalNode{Operator: op, Left: &lNode, Right: &rNode}, nil
+	return &values.ConditionalNode{Operator: op, Left: &lNode, Right: &rNode}, nil
 }
 
 // 文字列解析式
-func (p *Parser) analyzeStringFormula() (*codes.ConditionalNode, error) {
+func (p *Parser) analyzeStringFormula() (*values.ConditionalNode, error) {
 	var falseFlag bool
 	if p.tokenIs(token.NOT, 0) {
 		falseFlag = true
@@ -809,25 +1172,25 @@ func (p *Parser) analyzeStringFormula() (*codes.ConditionalNode, error) {
 	if err != nil {
 		return nil, err
 	}
-	lNode := codes.ConditionalNode{Var: left}
+	lNode := values.ConditionalNode{Var: left}
 
 	if !p.tokenIs(token.DOT, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '.'"))
+		return nil, p.errorf("syntax error: cannot find '.'")
 	}
 	p.index++
 
-	var op codes.OperatorKind
+	var op values.OperatorKind
 	if p.tokenIs(token.STARTWITH, 0) {
-		op = codes.STARTWITH
+		op = values.STARTWITH
 	} else if p.tokenIs(token.ENDWITH, 0) {
-		op = codes.ENDWITH
+		op = values.ENDWITH
 	} else {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'startwith' or 'endwith'"))
+		return nil, p.errorf("syntax error: cannot find 'startwith' or 'endwith'")
 	}
 	p.index++
 
 	if !p.tokenIs(token.LPAREN, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 
@@ -835,29 +1198,37 @@ func (p *Parser) analyzeStringFormula() (*codes.ConditionalNode, error) {
 	if err != nil {
 		return nil, err
 	}
-	rNode := codes.ConditionalNode{Var: right}
+	rNode := values.ConditionalNode{Var: right}
 
 	if !p.tokenIs(token.RPAREN, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
 
-	return &codes.ConditionalNode{Operator: op, Left: &lNode, Right: &rNode, False: falseFlag}, nil
+	return &values.ConditionalNode{Operator: op, Left: &lNode, Right: &rNode, False: falseFlag}, nil
 }
 
 // 比較演算子
-func (p *Parser) conditionalOperator() (codes.OperatorKind, error) {
-	var op codes.OperatorKind
-
-	// "==", "!="
-	if p.index >= len(p.tokens) || (p.tokens[p.index].Kind != token.EQUAL && p.tokens[p.index].Kind != token.NOTEQUAL) {
-		return -1, errors.New(fmt.Sprintf("syntax error: cannot find conditional operator"))
-	}
-
-	if p.tokens[p.index].Kind == token.EQUAL {
-		op = codes.EQUAL
-	} else if p.tokens[p.index].Kind == token.NOTEQUAL {
-		op = codes.NOTEQUAL
+func (p *Parser) conditionalOperator() (values.OperatorKind, error) {
+	var op values.OperatorKind
+
+	// "==", "!=", "<", "<=", ">", ">=", "in"
+	if p.tokenIs(token.EQUAL, 0) {
+		op = values.EQUAL
+	} else if p.tokenIs(token.NOTEQUAL, 0) {
+		op = values.NOTEQUAL
+	} else if p.tokenIs(token.LESS, 0) {
+		op = values.LESS
+	} else if p.tokenIs(token.LESSEQUAL, 0) {
+		op = values.LESSEQUAL
+	} else if p.tokenIs(token.GREATER, 0) {
+		op = values.GREATER
+	} else if p.tokenIs(token.GREATEREQUAL, 0) {
+		op = values.GREATEREQUAL
+	} else if p.tokenIs(token.IN, 0) {
+		op = values.IN
+	} else {
+		return -1, p.errorf("syntax error: cannot find conditional operator")
 	}
 
 	p.index++
@@ -870,6 +1241,7 @@ func (p *Parser) defineVariable() (codes.Code, error) {
 	var err error
 
 	// 変数名
+	pos := p.pos()
 	vName, err := p.variableName()
 	if err != nil {
 		return nil, err
@@ -877,7 +1249,7 @@ func (p *Parser) defineVariable() (codes.Code, error) {
 
 	// ":="
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.DEFINE {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ':='"))
+		return nil, p.errorf("syntax error: cannot find ':='")
 	}
 
 	p.index++
@@ -887,7 +1259,7 @@ func (p *Parser) defineVariable() (codes.Code, error) {
 		return nil, err
 	}
 
-	defCode := codes.Define{Kind: codes.DEFINE, Key: vName, Value: value}
+	defCode := codes.Define{Kind: codes.DEFINE, Pos: pos, Key: vName, Value: value}
 
 	return defCode, nil
 }
@@ -895,6 +1267,7 @@ func (p *Parser) defineVariable() (codes.Code, error) {
 // 変数代入文
 func (p *Parser) assignVariable() (codes.Code, error) {
 	// 変数名
+	pos := p.pos()
 	vName, err := p.variableName()
 	if err != nil {
 		return nil, err
@@ -902,7 +1275,7 @@ func (p *Parser) assignVariable() (codes.Code, error) {
 
 	// "="
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.ASSIGN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '='"))
+		return nil, p.errorf("syntax error: cannot find '='")
 	}
 	p.index++
 
@@ -911,7 +1284,7 @@ func (p *Parser) assignVariable() (codes.Code, error) {
 		return nil, err
 	}
 
-	defCode := codes.Assign{Kind: codes.ASSIGN, Key: vName, Value: value}
+	defCode := codes.Assign{Kind: codes.ASSIGN, Pos: pos, Key: vName, Value: value}
 
 	return defCode, nil
 }
@@ -919,72 +1292,151 @@ func (p *Parser) assignVariable() (codes.Code, error) {
 // 代入値
 func (p *Parser) assignValue() (values.Value, error) {
 	stackIndex := p.index
+	// mapは中の誤りをそのまま報告する
+	if p.tokenIs(token.LBRACE, 0) && (p.tokenIs(token.RBRACE, 1) || p.tokenIs(token.STRING, 1) && p.tokenIs(token.COLON, 2)) {
+		return p.mapLiteral()
+	}
+
+	// どの読み方でも失敗した場合は、最も先まで読めたものの誤りを報告する
+	var farthestErr error
+	farthest := stackIndex
+	recordErr := func(err error) {
+		if p.index > farthest {
+			farthestErr = err
+			farthest = p.index
+		}
+		p.index = stackIndex
+	}
+
 	complexValue, err := p.complexAssignValue()
 	if err == nil {
 		return complexValue, nil
 	}
+	recordErr(err)
+
+	// 単一の値だけからなる条件判定式は単一代入式として読む
+	condition, err := p.conditionalFormula()
+	if err == nil && (condition.Operator != values.TRUTH || condition.False) {
+		return values.Condition{Kind: values.CONDITION, Node: *condition}, nil
+	}
+	if err == nil {
+		// 真偽値式として読んだ単一代入式をそのまま使い、読み直さない
+		return condition.Var, nil
+	}
 	p.index = stackIndex
 
 	singleValue, err := p.singleAssignFormula()
 	if err == nil {
 		return singleValue, nil
 	}
+	recordErr(err)
 
-	return nil, errors.New(fmt.Sprintf("syntax error: cannot find assign value"))
+	if farthestErr != nil {
+		p.index = farthest
+		return nil, farthestErr
+	}
+	return nil, p.errorf("syntax error: cannot find assign value")
 }
 
 // 単一代入式
+// "+" は文字列の連結と数値の加算を兼ねるため AddString にまとめる
 func (p *Parser) singleAssignFormula() (values.Value, error) {
-	var (
-		value values.Value
-		err   error
-	)
-
-	stackIndex := p.index
-	value, err = p.trimStringFormula()
+	value, err := p.multiplicativeFormula()
 	if err != nil {
-		p.index = stackIndex
-		value, err = p.singleAssignValue()
+		return value, err
+	}
+
+	var vls []values.Value
+	for p.tokenIs(token.PLUS, 0) || p.tokenIs(token.MINUS, 0) {
+		isPlus := p.tokenIs(token.PLUS, 0)
+		// +, -
+		p.index++
+
+		right, err := p.multiplicativeFormula()
 		if err != nil {
-			return value, err
+			return right, err
 		}
-	}
 
-	if !p.tokenIs(token.PLUS, 0) {
-		return value, nil
+		if isPlus {
+			if vls == nil {
+				vls = append(vls, value)
+			}
+			vls = append(vls, right)
+			value = values.AddString{Kind: values.ADDSTRING, Values: vls}
+		} else {
+			value = values.Arithmetic{Kind: values.ARITHMETIC, Operator: values.SUB, Left: value, Right: right}
+			vls = nil
+		}
 	}
 
-	var vls []values.Value
-	vls = append(vls, value)
+	return value, nil
+}
+
+// 乗除算式
+func (p *Parser) multiplicativeFormula() (values.Value, error) {
+	value, err := p.operand()
+	if err != nil {
+		return value, err
+	}
 
-	for p.tokenIs(token.PLUS, 0) {
-		// +
+	for p.tokenIs(token.ASTERISK, 0) || p.tokenIs(token.SLASH, 0) || p.tokenIs(token.PERCENT, 0) {
+		var op values.ArithOperator
+		if p.tokenIs(token.ASTERISK, 0) {
+			op = values.MUL
+		} else if p.tokenIs(token.SLASH, 0) {
+			op = values.DIV
+		} else {
+			op = values.MOD
+		}
+		// *, /, %
 		p.index++
 
-		stackIndex = p.index
-		value, err = p.trimStringFormula()
+		right, err := p.operand()
 		if err != nil {
-			p.index = stackIndex
-			value, err = p.singleAssignValue()
-			if err != nil {
-				return value, err
-			}
+			return right, err
 		}
-		vls = append(vls, value)
+
+		value = values.Arithmetic{Kind: values.ARITHMETIC, Operator: op, Left: value, Right: right}
 	}
 
-	return values.AddString{Kind: values.ADDSTRING, Values: vls}, nil
+	return value, nil
 }
 
-// 文字列除去式
-func (p *Parser) trimStringFormula() (values.TrimString, error) {
+// 被演算子
+func (p *Parser) operand() (values.Value, error) {
+	if p.tokenIs(token.MINUS, 0) {
+		// 単項マイナスは 0 からの減算として扱う
+		p.index++
+
+		value, err := p.operand()
+		if err != nil {
+			return value, err
+		}
+
+		return values.Arithmetic{Kind: values.ARITHMETIC, Operator: values.SUB, Left: values.Number{Kind: values.NUMBER, Value: 0}, Right: value}, nil
+	}
+
 	target, err := p.singleAssignValue()
 	if err != nil {
-		return values.TrimString{}, err
+		return target, err
+	}
+
+	// 値の後ろの .trimLeft(), .unique() などは一度読んだ値をそのまま使う
+	if p.tokenIs(token.DOT, 0) && (p.tokenIs(token.TRIMLEFT, 1) || p.tokenIs(token.TRIMRIGHT, 1)) {
+		return p.trimStringFormula(target)
 	}
+	if p.tokenIs(token.DOT, 0) && p.isMethodName(1) {
+		return p.arrayMethods(target)
+	}
+
+	return target, nil
+}
 
+// 文字列除去式
+func (p *Parser) trimStringFormula(target values.Value) (values.TrimString, error) {
+	// .
 	if !p.tokenIs(token.DOT, 0) {
-		return values.TrimString{}, errors.New(fmt.Sprintf("syntax error: cannot find '.'"))
+		return values.TrimString{}, p.errorf("syntax error: cannot find '.'")
 	}
 	p.index++
 
@@ -994,12 +1446,12 @@ func (p *Parser) trimStringFormula() (values.TrimString, error) {
 	} else if p.tokenIs(token.TRIMRIGHT, 0) {
 		from = values.RIGHT
 	} else {
-		return values.TrimString{}, errors.New(fmt.Sprintf("syntax error: cannot find 'leftTrim' or 'rightTrim'"))
+		return values.TrimString{}, p.errorf("syntax error: cannot find 'leftTrim' or 'rightTrim'")
 	}
 	p.index++
 
 	if !p.tokenIs(token.LPAREN, 0) {
-		return values.TrimString{}, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return values.TrimString{}, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 
@@ -1009,7 +1461,7 @@ func (p *Parser) trimStringFormula() (values.TrimString, error) {
 	}
 
 	if !p.tokenIs(token.RPAREN, 0) {
-		return values.TrimString{}, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return values.TrimString{}, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
 
@@ -1022,10 +1474,44 @@ func (p *Parser) singleAssignValue() (values.Value, error) {
 		value := values.Literal{Kind: values.LITERAL, Value: p.tokens[p.index].Content}
 		p.index++
 		return value, nil
-	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.LBRACKET, 1) && p.tokenIs(token.NUMBER, 2) {
+	} else if p.tokenIs(token.TRUE, 0) || p.tokenIs(token.FALSE, 0) {
+		value := values.Bool{Kind: values.BOOL, Value: p.tokenIs(token.TRUE, 0)}
+		p.index++
+		return value, nil
+	} else if p.tokenIs(token.NUMBER, 0) {
+		number, err := strconv.Atoi(p.tokens[p.index].Content)
+		if err != nil {
+			return nil, p.errorf("syntax error: invalid number %s", p.tokens[p.index].Content)
+		}
+		p.index++
+		return values.Number{Kind: values.NUMBER, Value: number}, nil
+	} else if p.tokenIs(token.LPAREN, 0) {
+		// (
+		p.index++
+
+		value, err := p.singleAssignFormula()
+		if err != nil {
+			return nil, err
+		}
+
+		// )
+		if !p.tokenIs(token.RPAREN, 0) {
+			return nil, p.errorf("syntax error: cannot find ')'")
+		}
+		p.index++
+
+		return value, nil
+	} else if p.isFunctionCall() {
+		value, err := p.functionCallValue()
+		return value, err
+	} else if p.isSlice() {
+		value, err := p.sliceValue()
+		return value, err
+	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.LBRACKET, 1) && p.tokenIs(token.NUMBER, 2) && !p.tokenIs(token.LBRACKET, 4) && !p.tokenIs(token.QUESTION, 4) {
 		value, err := p.arrayElement()
 		return value, err
-	} else if p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.LBRACKET, 1) {
+	} else if p.tokenIs(token.IDENTIFIER, 0) && (p.tokenIs(token.LBRACKET, 1) || p.tokenIs(token.QUESTION, 1) && p.tokenIs(token.LBRACKET, 2)) {
+		// data?["18"] のように最初のキーにも ? を付けられる
 		value, err := p.mapValue()
 		return value, err
 	} else if p.tokenIs(token.IDENTIFIER, 0) {
@@ -1033,7 +1519,7 @@ func (p *Parser) singleAssignValue() (values.Value, error) {
 		value := values.Ident{Kind: values.IDENT, Name: vName}
 		return value, err
 	}
-	return nil, errors.New(fmt.Sprintf("syntax error: cannot find complex assign value"))
+	return nil, p.errorf("syntax error: cannot find complex assign value")
 }
 
 // 複合代入値
@@ -1046,7 +1532,22 @@ func (p *Parser) complexAssignValue() (values.Value, error) {
 	p.index = stackIndex
 	jsonData, err := p.jsonUnmarshal()
 	if err == nil {
-		return values.Map{Kind: values.MAP, Value: jsonData}, nil
+		return jsonData, nil
+	}
+	p.index = stackIndex
+	yamlData, err := p.yamlUnmarshal()
+	if err == nil {
+		return yamlData, nil
+	}
+	p.index = stackIndex
+	csvData, err := p.csvLoad()
+	if err == nil {
+		return csvData, nil
+	}
+	p.index = stackIndex
+	dotenvData, err := p.dotenvLoad()
+	if err == nil {
+		return dotenvData, nil
 	}
 	p.index = stackIndex
 	mapValue, err := p.mapKey()
@@ -1058,14 +1559,19 @@ func (p *Parser) complexAssignValue() (values.Value, error) {
 	if err == nil {
 		return splitArr, nil
 	}
-	return nil, errors.New(fmt.Sprintf("syntax error: cannot parse complex assign value"))
+	p.index = stackIndex
+	rangeValue, err := p.rangeFormula()
+	if err == nil {
+		return rangeValue, nil
+	}
+	return nil, p.errorf("syntax error: cannot parse complex assign value")
 }
 
 // 配列
 func (p *Parser) array() ([]string, error) {
 	// {
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LBRACE {
-		return []string{}, errors.New(fmt.Sprintf("syntax error: cannot find '{'"))
+		return []string{}, p.errorf("syntax error: cannot find '{'")
 	}
 
 	p.index++
@@ -1077,7 +1583,7 @@ func (p *Parser) array() ([]string, error) {
 
 	// }
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RBRACE {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '}'"))
+		return nil, p.errorf("syntax error: cannot find '}'")
 	}
 
 	p.index++
@@ -1085,11 +1591,66 @@ func (p *Parser) array() ([]string, error) {
 	return arrayValues, nil
 }
 
+// map
+// 要素の並びの後のカンマは省略できる
+func (p *Parser) mapLiteral() (values.MapLiteral, error) {
+	// {
+	if !p.tokenIs(token.LBRACE, 0) {
+		return values.MapLiteral{}, p.errorf("syntax error: cannot find '{'")
+	}
+	p.index++
+
+	mapLiteral := values.MapLiteral{Kind: values.MAPLITERAL}
+	for !p.tokenIs(token.RBRACE, 0) {
+		// キー
+		if !p.tokenIs(token.STRING, 0) {
+			return values.MapLiteral{}, p.errorf("syntax error: cannot find string")
+		}
+		pos := p.pos()
+		key := p.tokens[p.index].Content
+		for _, declared := range mapLiteral.Keys {
+			if declared == key {
+				return values.MapLiteral{}, p.errorfAt(pos, "semantic error: duplicate key %s in map", key)
+			}
+		}
+		p.index++
+
+		// :
+		if !p.tokenIs(token.COLON, 0) {
+			return values.MapLiteral{}, p.errorf("syntax error: cannot find ':'")
+		}
+		p.index++
+
+		// 値
+		value, err := p.assignValue()
+		if err != nil {
+			return values.MapLiteral{}, err
+		}
+
+		mapLiteral.Keys = append(mapLiteral.Keys, key)
+		mapLiteral.Values = append(mapLiteral.Values, value)
+
+		// ,
+		if !p.tokenIs(token.COMMA, 0) {
+			break
+		}
+		p.index++
+	}
+
+	// }
+	if !p.tokenIs(token.RBRACE, 0) {
+		return values.MapLiteral{}, p.errorf("syntax error: cannot find '}'")
+	}
+	p.index++
+
+	return mapLiteral, nil
+}
+
 // 配列要素
 func (p *Parser) arrayElement() (values.Element, error) {
 	// 変数名
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-		return values.Element{}, errors.New(fmt.Sprintf("syntax error: cannot find identifier"))
+		return values.Element{}, p.errorf("syntax error: cannot find identifier")
 	}
 
 	name := p.tokens[p.index].Content
@@ -1097,14 +1658,14 @@ func (p *Parser) arrayElement() (values.Element, error) {
 
 	// [
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LBRACKET {
-		return values.Element{}, errors.New(fmt.Sprintf("syntax error: cannot find left bracket"))
+		return values.Element{}, p.errorf("syntax error: cannot find left bracket")
 	}
 
 	p.index++
 
 	// 数字
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.NUMBER {
-		return values.Element{}, errors.New(fmt.Sprintf("syntax error: cannot find number"))
+		return values.Element{}, p.errorf("syntax error: cannot find number")
 	}
 
 	index, err := strconv.Atoi(p.tokens[p.index].Content)
@@ -1115,7 +1676,7 @@ func (p *Parser) arrayElement() (values.Element, error) {
 
 	// [
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RBRACKET {
-		return values.Element{}, errors.New(fmt.Sprintf("syntax error: cannot find right bracket"))
+		return values.Element{}, p.errorf("syntax error: cannot find right bracket")
 	}
 
 	p.index++
@@ -1129,7 +1690,7 @@ func (p *Parser) rowOfStrings() ([]string, error) {
 
 	// 文字列
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.STRING {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find string"))
+		return nil, p.errorf("syntax error: cannot find string")
 	}
 
 	strings = append(strings, p.tokens[p.index].Content)
@@ -1146,7 +1707,7 @@ func (p *Parser) rowOfStrings() ([]string, error) {
 
 		// 文字列
 		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.STRING {
-			return strings, errors.New(fmt.Sprintf("syntax error: cannot find string"))
+			return strings, p.errorf("syntax error: cannot find string")
 		}
 
 		strings = append(strings, p.tokens[p.index].Content)
@@ -1158,51 +1719,93 @@ func (p *Parser) rowOfStrings() ([]string, error) {
 }
 
 // Json読み取り
-func (p *Parser) jsonUnmarshal() (map[string]interface{}, error) {
-	// JsonUnmarshal
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.JSONUNMARSHAL {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find JsonUnmarshal"))
+// ファイルは生成時に読むので、パスには任意の式を書ける
+func (p *Parser) jsonUnmarshal() (values.JsonUnmarshal, error) {
+	path, _, err := p.dataFileArgs(token.JSONUNMARSHAL, "JsonUnmarshal", false)
+	if err != nil {
+		return values.JsonUnmarshal{}, err
 	}
-	p.index++
 
-	// (
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ("))
+	return values.JsonUnmarshal{Kind: values.JSONUNMARSHAL, Path: path, Dir: filepath.Dir(p.filePath)}, nil
+}
+
+// Csv読み取り
+// 1行目をヘッダとして、各行をヘッダをキーにしたmapにする
+func (p *Parser) csvLoad() (values.CsvLoad, error) {
+	path, _, err := p.dataFileArgs(token.CSVLOAD, "CsvLoad", false)
+	if err != nil {
+		return values.CsvLoad{}, err
 	}
-	p.index++
 
-	// 文字列
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.STRING {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find string"))
+	return values.CsvLoad{Kind: values.CSVLOAD, Path: path, Dir: filepath.Dir(p.filePath)}, nil
+}
+
+// .env読み取り
+func (p *Parser) dotenvLoad() (values.DotenvLoad, error) {
+	path, _, err := p.dataFileArgs(token.DOTENVLOAD, "DotenvLoad", false)
+	if err != nil {
+		return values.DotenvLoad{}, err
 	}
 
-	fileName := p.tokens[p.index].Content
-	bytes, err := os.ReadFile(fileName)
+	return values.DotenvLoad{Kind: values.DOTENVLOAD, Path: path, Dir: filepath.Dir(p.filePath)}, nil
+}
+
+// Yaml読み取り
+// 2番目の引数で、ファイル中の何番目のドキュメントを読むかを指定できる
+func (p *Parser) yamlUnmarshal() (values.YamlUnmarshal, error) {
+	path, document, err := p.dataFileArgs(token.YAMLUNMARSHAL, "YamlUnmarshal", true)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("failed to open %s", fileName))
+		return values.YamlUnmarshal{}, err
 	}
 
-	var jsonData map[string]interface{}
-	if err := json.Unmarshal(bytes, &jsonData); err != nil {
-		return nil, errors.New(fmt.Sprintf("failed to unmarshal %s", fileName))
+	return values.YamlUnmarshal{Kind: values.YAMLUNMARSHAL, Path: path, Document: document, Dir: filepath.Dir(p.filePath)}, nil
+}
+
+// dataFileArgs parses `name(path)` of the data file loaders and returns the path.
+// hasOption が true の場合は `name(path, option)` の2番目の引数も読む
+func (p *Parser) dataFileArgs(kind token.TokenKind, name string, hasOption bool) (values.Value, values.Value, error) {
+	// 関数名
+	if !p.tokenIs(kind, 0) {
+		return nil, nil, p.errorf("syntax error: cannot find %s", name)
 	}
+	p.index++
 
+	// (
+	if !p.tokenIs(token.LPAREN, 0) {
+		return nil, nil, p.errorf("syntax error: cannot find (")
+	}
 	p.index++
 
+	// ファイル名
+	path, err := p.singleAssignFormula()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// , 2番目の引数
+	var option values.Value
+	if hasOption && p.tokenIs(token.COMMA, 0) {
+		p.index++
+		option, err = p.singleAssignFormula()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// )
-	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find )"))
+	if !p.tokenIs(token.RPAREN, 0) {
+		return nil, nil, p.errorf("syntax error: cannot find )")
 	}
 	p.index++
 
-	return jsonData, nil
+	return path, option, nil
 }
 
 // mapキー
 func (p *Parser) mapKey() (values.MapKey, error) {
 	// 変数名
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-		return values.MapKey{}, errors.New(fmt.Sprintf("syntax error: cannot find identifier"))
+		return values.MapKey{}, p.errorf("syntax error: cannot find identifier")
 	}
 
 	name := p.tokens[p.index].Content
@@ -1210,14 +1813,14 @@ func (p *Parser) mapKey() (values.MapKey, error) {
 
 	// .
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.DOT {
-		return values.MapKey{}, errors.New(fmt.Sprintf("syntax error: cannot find ."))
+		return values.MapKey{}, p.errorf("syntax error: cannot find .")
 	}
 
 	p.index++
 
 	// keys
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.KEYS {
-		return values.MapKey{}, errors.New(fmt.Sprintf("syntax error: cannot find keys"))
+		return values.MapKey{}, p.errorf("syntax error: cannot find keys")
 	}
 
 	p.index++
@@ -1229,21 +1832,32 @@ func (p *Parser) mapKey() (values.MapKey, error) {
 func (p *Parser) mapValue() (values.MapValue, error) {
 	// 変数名
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-		return values.MapValue{}, errors.New(fmt.Sprintf("syntax error: cannot find identifier"))
+		return values.MapValue{}, p.errorf("syntax error: cannot find identifier")
 	}
 
 	name := p.tokens[p.index].Content
 	p.index++
 
 	var keys []values.Value
+	safeFrom := -1
 	for {
+		// ?[ は直前のキーから先を無くてもよいものとする (最初の ?[ は最初のキーから)
+		if p.tokenIs(token.QUESTION, 0) && p.tokenIs(token.LBRACKET, 1) {
+			if safeFrom < 0 {
+				safeFrom = len(keys) - 1
+				if safeFrom < 0 {
+					safeFrom = 0
+				}
+			}
+			p.index++
+		}
 		if !p.tokenIs(token.LBRACKET, 0) {
 			break
 		}
 
 		// [
 		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LBRACKET {
-			return values.MapValue{}, errors.New(fmt.Sprintf("syntax error: cannot find left bracket"))
+			return values.MapValue{}, p.errorf("syntax error: cannot find left bracket")
 		}
 
 		p.index++
@@ -1256,13 +1870,21 @@ func (p *Parser) mapValue() (values.MapValue, error) {
 
 		// ]
 		if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RBRACKET {
-			return values.MapValue{}, errors.New(fmt.Sprintf("syntax error: cannot find right bracket"))
+			return values.MapValue{}, p.errorf("syntax error: cannot find right bracket")
 		}
 
 		p.index++
 	}
 
-	return values.MapValue{Kind: values.MAPVALUE, Name: name, Keys: keys}, nil
+	var optional []bool
+	if safeFrom >= 0 {
+		optional = make([]bool, len(keys))
+		for i := safeFrom; i < len(keys); i++ {
+			optional[i] = true
+		}
+	}
+
+	return values.MapValue{Kind: values.MAPVALUE, Name: name, Keys: keys, Optional: optional}, nil
 }
 
 // 文字列分割式
@@ -1273,17 +1895,17 @@ func (p *Parser) splitStringFormula() (values.SplitString, error) {
 	}
 
 	if !p.tokenIs(token.DOT, 0) {
-		return values.SplitString{}, errors.New(fmt.Sprintf("syntax error: cannot find '.'"))
+		return values.SplitString{}, p.errorf("syntax error: cannot find '.'")
 	}
 	p.index++
 
 	if !p.tokenIs(token.SPLIT, 0) {
-		return values.SplitString{}, errors.New(fmt.Sprintf("syntax error: cannot find 'split'"))
+		return values.SplitString{}, p.errorf("syntax error: cannot find 'split'")
 	}
 	p.index++
 
 	if !p.tokenIs(token.LPAREN, 0) {
-		return values.SplitString{}, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return values.SplitString{}, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 
@@ -1293,13 +1915,52 @@ func (p *Parser) splitStringFormula() (values.SplitString, error) {
 	}
 
 	if !p.tokenIs(token.RPAREN, 0) {
-		return values.SplitString{}, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return values.SplitString{}, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
 
 	return values.SplitString{Kind: values.SPLITSTRING, Target: target, Sep: sep}, nil
 }
 
+// 範囲式
+func (p *Parser) rangeFormula() (values.Range, error) {
+	// range
+	if !p.tokenIs(token.RANGE, 0) {
+		return values.Range{}, p.errorf("syntax error: cannot find 'range'")
+	}
+	p.index++
+
+	// (
+	if !p.tokenIs(token.LPAREN, 0) {
+		return values.Range{}, p.errorf("syntax error: cannot find '('")
+	}
+	p.index++
+
+	start, err := p.singleAssignFormula()
+	if err != nil {
+		return values.Range{}, err
+	}
+
+	// ,
+	if !p.tokenIs(token.COMMA, 0) {
+		return values.Range{}, p.errorf("syntax error: cannot find ','")
+	}
+	p.index++
+
+	end, err := p.singleAssignFormula()
+	if err != nil {
+		return values.Range{}, err
+	}
+
+	// )
+	if !p.tokenIs(token.RPAREN, 0) {
+		return values.Range{}, p.errorf("syntax error: cannot find ')'")
+	}
+	p.index++
+
+	return values.Range{Kind: values.RANGE, Start: start, End: end}, nil
+}
+
 // if節
 func (p *Parser) ifSection() ([]codes.Code, error) {
 	var ifCodes []codes.Code
@@ -1307,14 +1968,15 @@ func (p *Parser) ifSection() ([]codes.Code, error) {
 
 	// "if"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IF {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'if'"))
+		return nil, p.errorf("syntax error: cannot find 'if'")
 	}
+	pos := p.pos()
 
 	p.index++
 
 	// "("
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 
@@ -1324,12 +1986,12 @@ func (p *Parser) ifSection() ([]codes.Code, error) {
 		return nil, err
 	}
 
-	ifCode := codes.If{Kind: codes.IF, Condition: *condition}
+	ifCode := codes.If{Kind: codes.IF, Pos: pos, Condition: *condition}
 	ifCodes = append(ifCodes, ifCode)
 
 	// ")"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
 
@@ -1354,19 +2016,20 @@ func (p *Parser) elifSection() ([]codes.Code, error) {
 
 	// "else"
 	if !p.tokenIs(token.ELSE, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'else'"))
+		return nil, p.errorf("syntax error: cannot find 'else'")
 	}
+	pos := p.pos()
 	p.index++
 
 	// "if"
 	if !p.tokenIs(token.IF, 0) {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'if'"))
+		return nil, p.errorf("syntax error: cannot find 'if'")
 	}
 	p.index++
 
 	// "("
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 
@@ -1376,12 +2039,12 @@ func (p *Parser) elifSection() ([]codes.Code, error) {
 		return nil, err
 	}
 
-	elifCode := codes.Elif{Kind: codes.ELIF, Condition: *condition}
+	elifCode := codes.Elif{Kind: codes.ELIF, Pos: pos, Condition: *condition}
 	elifCodes = append(elifCodes, elifCode)
 
 	// ")"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
 
@@ -1407,10 +2070,10 @@ func (p *Parser) elseSection() ([]codes.Code, error) {
 
 	// "else"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.ELSE {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'else'"))
+		return nil, p.errorf("syntax error: cannot find 'else'")
 	}
 
-	elseCode := codes.Else{Kind: codes.ELSE}
+	elseCode := codes.Else{Kind: codes.ELSE, Pos: p.pos()}
 	elseCodes = append(elseCodes, elseCode)
 
 	p.index++
@@ -1434,14 +2097,15 @@ func (p *Parser) forBlock() ([]codes.Code, error) {
 	var forCodes []codes.Code
 	// "for"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.FOR {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find 'for'"))
+		return nil, p.errorf("syntax error: cannot find 'for'")
 	}
+	pos := p.pos()
 
 	p.index++
 
 	// "("
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.LPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return nil, p.errorf("syntax error: cannot find '('")
 	}
 
 	p.index++
@@ -1452,15 +2116,49 @@ func (p *Parser) forBlock() ([]codes.Code, error) {
 		return nil, err
 	}
 
+	// キーと値の組
+	var valueName string
+	if p.tokenIs(token.COMMA, 0) {
+		p.index++
+
+		valueName, err = p.variableName()
+		if err != nil {
+			return nil, err
+		}
+		if valueName == itrName {
+			return nil, p.errorf("semantic error: %s is declared twice in for", valueName)
+		}
+	}
+
 	// "in"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IN {
-		return nil, errors.New(fmt.Sprintf("syntax errir: cannot find 'in'"))
+		return nil, p.errorf("syntax errir: cannot find 'in'")
 	}
 
 	p.index++
 
 	var value values.Value
-	if p.index+1 < len(p.tokens) && p.tokens[p.index].Kind == token.IDENTIFIER && p.tokens[p.index+1].Kind == token.DOT {
+	if p.tokenIs(token.RANGE, 0) {
+		value, err = p.rangeFormula()
+		if err != nil {
+			return nil, err
+		}
+	} else if p.isFunctionCall() {
+		value, err = p.functionCallValue()
+		if err != nil {
+			return nil, err
+		}
+	} else if p.isArrayMethod() {
+		value, err = p.arrayMethodFormula()
+		if err != nil {
+			return nil, err
+		}
+	} else if p.isSlice() {
+		value, err = p.sliceValue()
+		if err != nil {
+			return nil, err
+		}
+	} else if p.index+1 < len(p.tokens) && p.tokens[p.index].Kind == token.IDENTIFIER && p.tokens[p.index+1].Kind == token.DOT {
 		value, err = p.mapKey()
 		if err != nil {
 			return nil, err
@@ -1480,16 +2178,18 @@ func (p *Parser) forBlock() ([]codes.Code, error) {
 
 	// ")"
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.RPAREN {
-		return nil, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return nil, p.errorf("syntax error: cannot find ')'")
 	}
 
 	p.index++
 
-	forCode := codes.For{Kind: codes.FOR, ItrName: itrName, ArrayValue: value}
+	forCode := codes.For{Kind: codes.FOR, Pos: pos, ItrName: itrName, ValueName: valueName, ArrayValue: value}
 	forCodes = append(forCodes, forCode)
 
 	// 記述ブロック群
+	p.loopDepth++
 	descCodes, err := p.descriptionBlockGroup()
+	p.loopDepth--
 	if err != nil {
 		return nil, err
 	}
@@ -1504,20 +2204,21 @@ func (p *Parser) forBlock() ([]codes.Code, error) {
 
 // 配列登録式
 func (p *Parser) appendArray() (codes.Append, error) {
+	pos := p.pos()
 	arrayName, err := p.variableName()
 	if err != nil {
 		return codes.Append{}, err
 	}
 	if !p.tokenIs(token.DOT, 0) {
-		return codes.Append{}, errors.New(fmt.Sprintf("syntax error: cannot find '.'"))
+		return codes.Append{}, p.errorf("syntax error: cannot find '.'")
 	}
 	p.index++
 	if !p.tokenIs(token.APPEND, 0) {
-		return codes.Append{}, errors.New(fmt.Sprintf("syntax error: cannot find 'append'"))
+		return codes.Append{}, p.errorf("syntax error: cannot find 'append'")
 	}
 	p.index++
 	if !p.tokenIs(token.LPAREN, 0) {
-		return codes.Append{}, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return codes.Append{}, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 	elem, err := p.singleAssignFormula()
@@ -1525,41 +2226,208 @@ func (p *Parser) appendArray() (codes.Append, error) {
 		return codes.Append{}, err
 	}
 	if !p.tokenIs(token.RPAREN, 0) {
-		return codes.Append{}, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return codes.Append{}, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
-	return codes.Append{Kind: codes.APPEND, Array: arrayName, Element: elem}, nil
+	return codes.Append{Kind: codes.APPEND, Pos: pos, Array: arrayName, Element: elem}, nil
+}
+
+// 配列操作式
+func (p *Parser) arrayMethodFormula() (values.Value, error) {
+	target, err := p.singleAssignValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.arrayMethods(target)
+}
+
+// 配列操作文
+// a.unique() は a = a.unique() として扱う
+func (p *Parser) arrayMethodStatement() (codes.Assign, error) {
+	pos := p.pos()
+	arrayName, err := p.variableName()
+	if err != nil {
+		return codes.Assign{}, err
+	}
+
+	value, err := p.arrayMethods(values.Ident{Kind: values.IDENT, Name: arrayName})
+	if err != nil {
+		return codes.Assign{}, err
+	}
+
+	return codes.Assign{Kind: codes.ASSIGN, Pos: pos, Key: arrayName, Value: value}, nil
+}
+
+// 配列操作の並び
+// .unique(), .reverse(), .filter(x => 条件) を繋げて書ける
+func (p *Parser) arrayMethods(target values.Value) (values.Value, error) {
+	value := target
+	for p.tokenIs(token.DOT, 0) && p.isMethodName(1) {
+		// .
+		p.index++
+
+		method := values.ArrayMethod{Kind: values.ARRAYMETHOD, Target: value}
+		switch p.tokens[p.index].Kind {
+		case token.UNIQUE:
+			method.Method = values.UNIQUE
+		case token.REVERSE:
+			method.Method = values.REVERSE
+		case token.FILTER:
+			method.Method = values.FILTER
+		}
+		p.index++
+
+		// (
+		if !p.tokenIs(token.LPAREN, 0) {
+			return nil, p.errorf("syntax error: cannot find '('")
+		}
+		p.index++
+
+		// 引数名 => 条件判定式
+		if method.Method == values.FILTER {
+			param, err := p.variableName()
+			if err != nil {
+				return nil, err
+			}
+			if !p.tokenIs(token.ARROW, 0) {
+				return nil, p.errorf("syntax error: cannot find '=>'")
+			}
+			p.index++
+
+			predicate, err := p.conditionalFormula()
+			if err != nil {
+				return nil, err
+			}
+			method.Param = param
+			method.Predicate = *predicate
+		}
+
+		// )
+		if !p.tokenIs(token.RPAREN, 0) {
+			return nil, p.errorf("syntax error: cannot find ')'")
+		}
+		p.index++
+
+		value = method
+	}
+
+	return value, nil
+}
+
+// 部分配列
+// a[1:3], a[:2], a[i:] の形
+func (p *Parser) sliceValue() (values.Slice, error) {
+	target, err := p.variableName()
+	if err != nil {
+		return values.Slice{}, err
+	}
+
+	// [
+	if !p.tokenIs(token.LBRACKET, 0) {
+		return values.Slice{}, p.errorf("syntax error: cannot find left bracket")
+	}
+	p.index++
+
+	slice := values.Slice{Kind: values.SLICE, Target: values.Ident{Kind: values.IDENT, Name: target}}
+	if !p.tokenIs(token.COLON, 0) {
+		slice.Start, err = p.singleAssignFormula()
+		if err != nil {
+			return values.Slice{}, err
+		}
+	}
+
+	// :
+	if !p.tokenIs(token.COLON, 0) {
+		return values.Slice{}, p.errorf("syntax error: cannot find ':'")
+	}
+	p.index++
+
+	if !p.tokenIs(token.RBRACKET, 0) {
+		slice.End, err = p.singleAssignFormula()
+		if err != nil {
+			return values.Slice{}, err
+		}
+	}
+
+	// ]
+	if !p.tokenIs(token.RBRACKET, 0) {
+		return values.Slice{}, p.errorf("syntax error: cannot find right bracket")
+	}
+	p.index++
+
+	return slice, nil
 }
 
 // 配列ソート文
 func (p *Parser) sortArray() (codes.Sort, error) {
+	pos := p.pos()
 	arrayName, err := p.variableName()
 	if err != nil {
 		return codes.Sort{}, err
 	}
 	if !p.tokenIs(token.DOT, 0) {
-		return codes.Sort{}, errors.New(fmt.Sprintf("syntax error: cannot find '.'"))
+		return codes.Sort{}, p.errorf("syntax error: cannot find '.'")
 	}
 	p.index++
-	if !p.tokenIs(token.SORT, 0) {
-		return codes.Sort{}, errors.New(fmt.Sprintf("syntax error: cannot find 'sort'"))
+	bySemver := p.tokenIs(token.SORTSEMVER, 0)
+	if !p.tokenIs(token.SORT, 0) && !bySemver {
+		return codes.Sort{}, p.errorf("syntax error: cannot find 'sort' or 'sortSemver'")
 	}
 	p.index++
 	if !p.tokenIs(token.LPAREN, 0) {
-		return codes.Sort{}, errors.New(fmt.Sprintf("syntax error: cannot find '('"))
+		return codes.Sort{}, p.errorf("syntax error: cannot find '('")
 	}
 	p.index++
 	if !p.tokenIs(token.RPAREN, 0) {
-		return codes.Sort{}, errors.New(fmt.Sprintf("syntax error: cannot find ')'"))
+		return codes.Sort{}, p.errorf("syntax error: cannot find ')'")
 	}
 	p.index++
-	return codes.Sort{Kind: codes.SORT, Array: arrayName}, nil
+	return codes.Sort{Kind: codes.SORT, Pos: pos, Array: arrayName, BySemver: bySemver}, nil
+}
+
+// 呼び出す関数名
+// 名前空間付きの名前 ns.name も受け付け、呼び出す関数の内部名と組み込み関数かどうかを返す
+func (p *Parser) calleeName() (string, bool, error) {
+	pos := p.pos()
+
+	// keysは予約語だが組み込み関数の名前としても使う
+	if p.tokenIs(token.KEYS, 0) {
+		p.index++
+		return "keys", true, nil
+	}
+
+	name, err := p.functionName()
+	if err != nil {
+		return "", false, err
+	}
+
+	if p.tokenIs(token.DOT, 0) {
+		p.index++
+		member, err := p.functionName()
+		if err != nil {
+			return "", false, err
+		}
+		name += "." + member
+	}
+
+	if funcName, ok := p.scope[name]; ok {
+		return funcName, false, nil
+	}
+	if _, ok := builtins[name]; ok {
+		return name, true, nil
+	}
+	if !p.importFailed {
+		return "", false, p.errorfAt(pos, "semantic error: %s is not defined", name)
+	}
+
+	return name, false, nil
 }
 
 // 関数名
 func (p *Parser) functionName() (string, error) {
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-		return "", errors.New(fmt.Sprintf("syntax error: cannot find an identifier"))
+		return "", p.errorf("syntax error: cannot find an identifier")
 	}
 
 	funcName := p.tokens[p.index].Content
@@ -1572,7 +2440,7 @@ func (p *Parser) functionName() (string, error) {
 // 変数名
 func (p *Parser) variableName() (string, error) {
 	if p.index >= len(p.tokens) || p.tokens[p.index].Kind != token.IDENTIFIER {
-		return "", errors.New(fmt.Sprintf("syntax error: cannot find an identifier"))
+		return "", p.errorf("syntax error: cannot find an identifier")
 	}
 
 	varName := p.tokens[p.index].Content
@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ty-bnn/myriad/pkg/model/codes"
+	"github.com/ty-bnn/myriad/pkg/model/values"
+	"github.com/ty-bnn/myriad/pkg/tokenizer"
+)
+
+// 誤りから回復した後も、元の誤りが全て報告されることを確かめる
+func TestParseRecovery(t *testing.T) {
+	const foo = "foo(a) {\n    return a\n}\n\n"
+
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "call statement missing )",
+			src:  foo + "main() {\n    foo(\"x\"\n    {{- FROM debian -}}\n}\n",
+			want: []string{"t.my:7:5: syntax error: cannot find ')'"},
+		},
+		{
+			name: "call statement missing ) before }",
+			src:  foo + "main() {\n    foo(\"x\"\n}\n",
+			want: []string{"t.my:7:1: syntax error: cannot find ')'"},
+		},
+		{
+			name: "call statement missing ) in if",
+			src:  foo + "main() {\n    if (true) {\n        foo(\"x\"\n    }\n    {{- FROM debian -}}\n}\n",
+			want: []string{"t.my:8:5: syntax error: cannot find ')'"},
+		},
+		{
+			name: "call missing ) in a definition",
+			src:  foo + "main() {\n    x := foo(\"x\"\n    {{- FROM {{ x }} -}}\n}\n",
+			want: []string{"t.my:7:5: syntax error: cannot find ')'"},
+		},
+		{
+			name: "call missing ) in an assignment",
+			src:  foo + "main() {\n    x := \"a\"\n    x = foo(\"x\"\n}\n",
+			want: []string{"t.my:8:1: syntax error: cannot find ')'"},
+		},
+		{
+			name: "errors after recovery",
+			src:  foo + "main() {\n    foo(\"x\"\n    {{- FROM debian -}}\n    x := \n}\n",
+			want: []string{
+				"t.my:7:5: syntax error: cannot find ')'",
+				"t.my:9:1: syntax error: cannot find assign value",
+			},
+		},
+		{
+			name: "errors in several functions",
+			src:  "bar() {\n    foo(\"x\"\n}\n\n" + foo + "main() {\n    bar(\n}\n",
+			want: []string{
+				"t.my:3:1: syntax error: cannot find ')'",
+				"t.my:11:1: syntax error: cannot find assign value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := tokenizer.NewTokenizer(tt.src, "t.my")
+			if err := tk.Tokenize(); err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+
+			err := NewParser(tk.Tokens, "t.my").Parse()
+			if err == nil {
+				t.Fatalf("Parse() error = nil, want %v", tt.want)
+			}
+			if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() error = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 入れ子の深い式も、入れ子の数に対して指数的に時間がかからずに読めることを確かめる
+func TestParseNestedValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "parentheses", value: strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100)},
+		{name: "calls", value: strings.Repeat("toUpper(", 6) + `"a"` + strings.Repeat(")", 6)},
+		{name: "trim", value: strings.Repeat("(", 30) + `"a"` + strings.Repeat(")", 30) + `.trimLeft("b")`},
+		{name: "method", value: "toUpper(" + strings.Repeat("(", 30) + "v" + strings.Repeat(")", 30) + ".reverse())"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "main() {\n    x := " + tt.value + "\n}\n"
+			tk := tokenizer.NewTokenizer(src, "t.my")
+			if err := tk.Tokenize(); err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				done <- NewParser(tk.Tokens, "t.my").Parse()
+			}()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Parse() did not finish in 2s")
+			}
+		})
+	}
+}
+
+func TestParseOptionalMapValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  values.MapValue
+	}{
+		{
+			name:  "no optional key",
+			value: `data["18"]["version"]`,
+			want:  values.MapValue{Kind: values.MAPVALUE, Name: "data", Keys: []values.Value{lit("18"), lit("version")}},
+		},
+		{
+			name:  "optional first key",
+			value: `data?["18"]`,
+			want:  values.MapValue{Kind: values.MAPVALUE, Name: "data", Keys: []values.Value{lit("18")}, Optional: []bool{true}},
+		},
+		{
+			name:  "optional first key and the rest",
+			value: `data?["18"]["version"]`,
+			want:  values.MapValue{Kind: values.MAPVALUE, Name: "data", Keys: []values.Value{lit("18"), lit("version")}, Optional: []bool{true, true}},
+		},
+		{
+			name:  "optional later key",
+			value: `data["18"]?["version"]`,
+			want:  values.MapValue{Kind: values.MAPVALUE, Name: "data", Keys: []values.Value{lit("18"), lit("version")}, Optional: []bool{true, true}},
+		},
+		{
+			name:  "optional last key",
+			value: `data["18"]["extra"]?["name"]`,
+			want:  values.MapValue{Kind: values.MAPVALUE, Name: "data", Keys: []values.Value{lit("18"), lit("extra"), lit("name")}, Optional: []bool{false, true, true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "main() {\n    data := {}\n    x := " + tt.value + "\n}\n"
+			tk := tokenizer.NewTokenizer(src, "t.my")
+			if err := tk.Tokenize(); err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+
+			p := NewParser(tk.Tokens, "t.my")
+			if err := p.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var got values.Value
+			for _, code := range p.FuncToCodes["main"] {
+				if define, ok := code.(codes.Define); ok && define.Key == "x" {
+					got = define.Value
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() x = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func lit(s string) values.Literal {
+	return values.Literal{Kind: values.LITERAL, Value: s}
+}
+
+// 2つのインポートが衝突した場合、報告する関数名が実行ごとに変わらないことを確かめる
+func TestImportCollisionIsStable(t *testing.T) {
+	dir := t.TempDir()
+	lib := "a() {\n    {{- RUN a -}}\n}\n\nb() {\n    {{- RUN b -}}\n}\n\nc() {\n    {{- RUN c -}}\n}\n"
+	for _, name := range []string{"one.my", "two.my"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(lib), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := "import one from \"one.my\"\nimport two from \"two.my\"\n\nmain() {\n    a()\n}\n"
+	path := filepath.Join(dir, "main.my")
+	want := path + ":2:1: semantic error: a is already declared"
+
+	for i := 0; i < 20; i++ {
+		tk := tokenizer.NewTokenizer(src, path)
+		if err := tk.Tokenize(); err != nil {
+			t.Fatalf("Tokenize() error = %v", err)
+		}
+
+		err := NewParser(tk.Tokens, path).Parse()
+		if err == nil || err.Error() != want {
+			t.Fatalf("Parse() error = %v, want %s", err, want)
+		}
+	}
+}
+
+// インポートの循環は、最初に解析したファイルからの連鎖全体で報告する
+func TestImportCycleChain(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.my": "import b from \"b.my\"\n\na() {\n    b()\n}\n",
+		"b.my": "import a from \"a.my\"\n\nb() {\n    {{- RUN b -}}\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := "import a from \"a.my\"\n\nmain() {\n    a()\n}\n"
+	path := filepath.Join(dir, "main.my")
+	tk := tokenizer.NewTokenizer(src, path)
+	if err := tk.Tokenize(); err != nil {
+		t.Fatalf("Tokenize() error = %v", err)
+	}
+
+	err := NewParser(tk.Tokens, path).Parse()
+	a, b := filepath.Join(dir, "a.my"), filepath.Join(dir, "b.my")
+	want := "import cycle: " + strings.Join([]string{path, a, b, a}, " -> ")
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Parse() error = %v, want %s", err, want)
+	}
+}
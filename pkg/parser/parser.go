@@ -1,23 +1,51 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/ty-bnn/myriad/pkg/model/token"
 
 	"github.com/ty-bnn/myriad/pkg/model/codes"
 )
 
 type Parser struct {
-	tokens        []token.Token
-	FuncToCodes   map[string][]codes.Code
-	compiledFiles []string
-	index         int
-	filePath      string
+	tokens []token.Token
+	// FuncToCodes はインポートした関数も含め、内部名をキーに関数のコードを持つ
+	FuncToCodes map[string][]codes.Code
+	// scope はこのファイルから呼び出せる関数名を内部名に対応付ける
+	scope map[string]string
+	// prefix はこのファイルで定義した関数の内部名に付ける接頭辞
+	prefix string
+	// SearchPath はインポートやデータファイルを探すディレクトリ
+	SearchPath []string
+	loader     *loader
+	// インポートに失敗した場合、未定義の関数はそのファイルのものかもしれないので報告しない
+	importFailed bool
+	// 解析中のfor文の深さ
+	loopDepth int
+	index     int
+	filePath  string
+	errs      ErrorList
 }
 
 func NewParser(tokens []token.Token, filePath string) *Parser {
 	return &Parser{
 		tokens:      tokens,
 		FuncToCodes: make(map[string][]codes.Code),
+		scope:       make(map[string]string),
+		loader:      newLoader(),
 		filePath:    filePath,
 	}
 }
+
+// ErrorList is a list of errors found in one parse, in the order they were found.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, err := range l {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
@@ -3,34 +3,130 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/ty-bnn/myriad/pkg/model/token"
+	"github.com/ty-bnn/myriad/pkg/utils"
 
 	"github.com/ty-bnn/myriad/pkg/model/codes"
 )
 
-func (p *Parser) isCompiled(file string) bool {
-	for _, compiledFile := range p.compiledFiles {
-		if file == compiledFile {
-			return true
+// addFuncCodes adds the functions of an imported file.
+// 内部名はファイルごとに一意なので、同じファイルを複数回インポートしても衝突しない
+func (p *Parser) addFuncCodes(funcToCodes map[string][]codes.Code) {
+	for funcName, funcCodes := range funcToCodes {
+		if _, has := p.FuncToCodes[funcName]; has {
+			continue
 		}
+
+		p.FuncToCodes[funcName] = funcCodes
 	}
+}
 
-	return false
+// resolvePath resolves a path written in this file.
+func (p *Parser) resolvePath(name string) (string, error) {
+	return utils.ResolvePath(name, filepath.Dir(p.filePath), p.SearchPath)
 }
 
-func (p *Parser) addFuncCodes(funcToCodes map[string][]codes.Code) error {
-	for funcName, funcCodes := range funcToCodes {
-		if _, has := p.FuncToCodes[funcName]; has {
-			return errors.New(fmt.Sprintf("semantic error: %s is already declared", funcName))
+// isFunctionCall reports whether a function call, name( or ns.name(, starts at the current token.
+func (p *Parser) isFunctionCall() bool {
+	if (p.tokenIs(token.IDENTIFIER, 0) || p.tokenIs(token.KEYS, 0)) && p.tokenIs(token.LPAREN, 1) {
+		return true
+	}
+
+	return p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.DOT, 1) && p.tokenIs(token.IDENTIFIER, 2) && p.tokenIs(token.LPAREN, 3)
+}
+
+// isArrayMethod reports whether a method call on an array, such as a.unique(), starts at the current token.
+func (p *Parser) isArrayMethod() bool {
+	return p.tokenIs(token.IDENTIFIER, 0) && p.tokenIs(token.DOT, 1) && p.isMethodName(2)
+}
+
+// isMethodName reports whether the token at offset is the name of an array method.
+func (p *Parser) isMethodName(offset int) bool {
+	return p.tokenIs(token.UNIQUE, offset) || p.tokenIs(token.REVERSE, offset) || p.tokenIs(token.FILTER, offset)
+}
+
+// isSlice reports whether a slice, name[start:end], starts at the current token.
+// 対応する ] までに括弧の外の : があれば部分配列とみなす
+func (p *Parser) isSlice() bool {
+	if !p.tokenIs(token.IDENTIFIER, 0) || !p.tokenIs(token.LBRACKET, 1) {
+		return false
+	}
+
+	depth := 0
+	for i := p.index + 2; i < len(p.tokens); i++ {
+		switch p.tokens[i].Kind {
+		case token.LBRACKET, token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.RBRACKET:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case token.COLON:
+			if depth == 0 {
+				return true
+			}
+		case token.LBRACE, token.RBRACE:
+			return false
 		}
+	}
 
-		p.FuncToCodes[funcName] = funcCodes
+	return false
+}
+
+// declare makes the function callable by name from this file.
+func (p *Parser) declare(name string, funcName string, pos token.Position) error {
+	if declared, has := p.scope[name]; has && declared != funcName {
+		return p.errorfAt(pos, "semantic error: %s is already declared", name)
 	}
 
+	p.scope[name] = funcName
+
 	return nil
 }
 
+// declareFunctions declares the functions defined in this file before parsing them,
+// so that a function can be called before its definition.
+func (p *Parser) declareFunctions() {
+	depth := 0
+	for i := 0; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+		switch tok.Kind {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		case token.IDENTIFIER:
+			if depth > 0 || i+1 >= len(p.tokens) || p.tokens[i+1].Kind != token.LPAREN {
+				continue
+			}
+			pos := token.Position{File: p.filePath, Line: tok.Line, Column: tok.Column}
+			if err := p.declare(tok.Content, p.prefix+tok.Content, pos); err != nil {
+				p.addError(err)
+			}
+
+			// 引数の既定値に書かれた関数呼び出しは宣言ではないので、対応する ) まで読み飛ばす
+			parens := 0
+			for i++; i < len(p.tokens); i++ {
+				if p.tokens[i].Kind == token.LPAREN {
+					parens++
+				} else if p.tokens[i].Kind == token.RPAREN {
+					parens--
+					if parens == 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
 func (p *Parser) tokenIs(kind token.TokenKind, offset int) bool {
 	index := p.index + offset
 	if index >= len(p.tokens) {
@@ -38,3 +134,134 @@ func (p *Parser) tokenIs(kind token.TokenKind, offset int) bool {
 	}
 	return p.tokens[index].Kind == kind
 }
+
+// pos returns the position of the current token.
+// トークンを読み切っている場合は最後のトークンの位置を返す
+func (p *Parser) pos() token.Position {
+	if len(p.tokens) == 0 {
+		return token.Position{File: p.filePath, Line: 1, Column: 1}
+	}
+
+	tok := p.tokens[len(p.tokens)-1]
+	if p.index < len(p.tokens) {
+		tok = p.tokens[p.index]
+	}
+
+	return token.Position{File: p.filePath, Line: tok.Line, Column: tok.Column}
+}
+
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return p.errorfAt(p.pos(), format, a...)
+}
+
+func (p *Parser) errorfAt(pos token.Position, format string, a ...interface{}) error {
+	return &token.Error{Pos: pos, Err: errors.New(fmt.Sprintf(format, a...))}
+}
+
+// addError records err. インポート先のエラー一覧は展開して記録する
+func (p *Parser) addError(err error) {
+	if errs, ok := err.(ErrorList); ok {
+		p.errs = append(p.errs, errs...)
+		return
+	}
+
+	p.errs = append(p.errs, err)
+}
+
+// isValueOperator reports whether the current token is an operator that continues a value or a comparison.
+func (p *Parser) isValueOperator() bool {
+	switch {
+	case p.tokenIs(token.PLUS, 0), p.tokenIs(token.MINUS, 0), p.tokenIs(token.ASTERISK, 0), p.tokenIs(token.SLASH, 0),
+		p.tokenIs(token.PERCENT, 0), p.tokenIs(token.DOT, 0), p.tokenIs(token.EQUAL, 0), p.tokenIs(token.NOTEQUAL, 0),
+		p.tokenIs(token.LESS, 0), p.tokenIs(token.LESSEQUAL, 0), p.tokenIs(token.GREATER, 0), p.tokenIs(token.GREATEREQUAL, 0),
+		p.tokenIs(token.IN, 0):
+		return true
+	}
+
+	return false
+}
+
+// isStatementStart reports whether the current token can begin a statement in a description block group.
+func (p *Parser) isStatementStart() bool {
+	return p.tokenIs(token.DFBEGIN, 0) || p.tokenIs(token.DFARG, 0) || p.tokenIs(token.IDENTIFIER, 0) || p.tokenIs(token.IF, 0) || p.tokenIs(token.FOR, 0) || p.tokenIs(token.RETURN, 0) || p.tokenIs(token.DELETE, 0) ||
+		p.tokenIs(token.BREAK, 0) || p.tokenIs(token.CONTINUE, 0) || p.tokenIs(token.KEYS, 0)
+}
+
+// syncFunction skips tokens until the beginning of the next import, function or main.
+// 括弧の外にある境界まで読み飛ばし、必ず1トークン以上進める
+func (p *Parser) syncFunction(start int) {
+	if p.index <= start {
+		p.index = start + 1
+	}
+
+	depth := 0
+	for p.index < len(p.tokens) {
+		switch p.tokens[p.index].Kind {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		case token.IMPORT, token.MAIN:
+			if depth == 0 {
+				return
+			}
+		case token.IDENTIFIER:
+			if depth == 0 && p.tokenIs(token.LPAREN, 1) {
+				return
+			}
+		}
+		p.index++
+	}
+}
+
+// syncStatement skips tokens until the end of the statement in error.
+// 対応の取れた '}' か '-}}' の後から解析を再開し、現在のブロックを閉じる '}' は読まない
+// 文の途中の括弧を数え損ねないよう、文の先頭から読み直す
+func (p *Parser) syncStatement(start int) {
+	p.index = start
+	depth := 0
+	for p.index < len(p.tokens) {
+		switch p.tokens[p.index].Kind {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.index++
+				if p.isStatementStart() {
+					return
+				}
+				continue
+			}
+		case token.DFEND:
+			if depth == 0 {
+				p.index++
+				if p.isStatementStart() {
+					return
+				}
+				continue
+			}
+		}
+		p.index++
+	}
+}
+
+// syncDockerfile skips tokens until the end of the replace formula in error.
+// '}}' の後から解析を再開し、Dfileブロックを閉じる '-}}' は読まない
+func (p *Parser) syncDockerfile() {
+	for p.index < len(p.tokens) {
+		switch p.tokens[p.index].Kind {
+		case token.RDOUBLEBRA:
+			p.index++
+			return
+		case token.DFEND:
+			return
+		}
+		p.index++
+	}
+}
@@ -1,7 +1,6 @@
 package tokenizer
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
@@ -12,12 +11,14 @@ func (t *Tokenizer) Tokenize() error {
 	fmt.Printf("Tokenizing %s ...\n", t.filePath)
 
 	for t.p < len(t.data) {
+		start := t.p
 		if !t.isInDfBlock {
 			tok, err := t.TokenizeMyriad()
 			if err != nil {
 				return err
 			}
 			if tok != (token.Token{}) {
+				tok.Line, tok.Column = t.lineColumn(start)
 				t.Tokens = append(t.Tokens, tok)
 			}
 		} else {
@@ -26,6 +27,7 @@ func (t *Tokenizer) Tokenize() error {
 				return err
 			}
 			if tok != (token.Token{}) {
+				tok.Line, tok.Column = t.lineColumn(start)
 				t.Tokens = append(t.Tokens, tok)
 			}
 		}
@@ -66,31 +68,46 @@ func (t *Tokenizer) TokenizeMyriad() (token.Token, error) {
 			return token.Token{Kind: token.DFBEGIN, Content: "{{-"}, nil
 		} else {
 			t.p++
+			if t.isInReplace {
+				t.braceDepth++
+			}
 			return token.Token{Kind: token.LBRACE, Content: "{"}, nil
 		}
 	case '}':
-		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "}}" {
+		// }} は置換の中で { が全て閉じている場合だけ置換の終わりとし、それ以外は } 2つとして読む
+		if t.isInReplace && t.braceDepth == 0 && t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "}}" {
 			t.p += 2
 			t.isInDfBlock = true
+			t.isInReplace = false
 			return token.Token{Kind: token.RDOUBLEBRA, Content: "}}"}, nil
 		} else {
 			t.p++
+			if t.isInReplace && t.braceDepth > 0 {
+				t.braceDepth--
+			}
 			return token.Token{Kind: token.RBRACE, Content: "}"}, nil
 		}
 	case '.':
 		t.p++
 		return token.Token{Kind: token.DOT, Content: "."}, nil
+	case '?':
+		t.p++
+		return token.Token{Kind: token.QUESTION, Content: "?"}, nil
 	case ':':
 		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == ":=" {
 			t.p += 2
 			return token.Token{Kind: token.DEFINE, Content: ":="}, nil
 		} else {
-			return token.Token{}, errors.New(fmt.Sprintf("tokenize error: invalid token ':'"))
+			t.p++
+			return token.Token{Kind: token.COLON, Content: ":"}, nil
 		}
 	case '=':
 		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "==" {
 			t.p += 2
 			return token.Token{Kind: token.EQUAL, Content: "=="}, nil
+		} else if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "=>" {
+			t.p += 2
+			return token.Token{Kind: token.ARROW, Content: "=>"}, nil
 		} else {
 			t.p++
 			return token.Token{Kind: token.ASSIGN, Content: "="}, nil
@@ -108,14 +125,14 @@ func (t *Tokenizer) TokenizeMyriad() (token.Token, error) {
 			t.p += 2
 			return token.Token{Kind: token.AND, Content: "&&"}, nil
 		} else {
-			return token.Token{}, errors.New(fmt.Sprintf("tokenize error: invalid token '&'"))
+			return token.Token{}, t.errorf(t.p, "tokenize error: invalid token '&'")
 		}
 	case '|':
 		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "||" {
 			t.p += 2
 			return token.Token{Kind: token.OR, Content: "||"}, nil
 		} else {
-			return token.Token{}, errors.New(fmt.Sprintf("tokenize error: invalid token '|'"))
+			return token.Token{}, t.errorf(t.p, "tokenize error: invalid token '|'")
 		}
 	case '"':
 		// TODO: パース時に判断したい
@@ -127,7 +144,7 @@ func (t *Tokenizer) TokenizeMyriad() (token.Token, error) {
 			}
 			t.p++
 			if t.p == len(t.data) {
-				return token.Token{}, errors.New(fmt.Sprintf("tokenize error: cannot find '\"'"))
+				return token.Token{}, t.errorf(start-1, "tokenize error: cannot find '\"'")
 			}
 		}
 		content := t.data[start:t.p]
@@ -136,10 +153,56 @@ func (t *Tokenizer) TokenizeMyriad() (token.Token, error) {
 	case '+':
 		t.p++
 		return token.Token{Kind: token.PLUS, Content: "+"}, nil
+	case '-':
+		t.p++
+		return token.Token{Kind: token.MINUS, Content: "-"}, nil
+	case '*':
+		t.p++
+		return token.Token{Kind: token.ASTERISK, Content: "*"}, nil
+	case '%':
+		t.p++
+		return token.Token{Kind: token.PERCENT, Content: "%"}, nil
+	case '/':
+		if t.nextTokenIs("//") {
+			// 行コメント
+			for t.p < len(t.data) && !isNewLine(t.data[t.p]) {
+				t.p++
+			}
+			return token.Token{}, nil
+		} else if t.nextTokenIs("/*") {
+			// ブロックコメント
+			start := t.p
+			t.p += 2
+			for !t.nextTokenIs("*/") {
+				if t.p >= len(t.data) {
+					return token.Token{}, t.errorf(start, "tokenize error: cannot find '*/'")
+				}
+				t.p++
+			}
+			t.p += 2
+			return token.Token{}, nil
+		} else {
+			t.p++
+			return token.Token{Kind: token.SLASH, Content: "/"}, nil
+		}
 	case '<':
 		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "<<" {
 			t.p += 2
 			return token.Token{Kind: token.DOUBLELESS, Content: "<<"}, nil
+		} else if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == "<=" {
+			t.p += 2
+			return token.Token{Kind: token.LESSEQUAL, Content: "<="}, nil
+		} else {
+			t.p++
+			return token.Token{Kind: token.LESS, Content: "<"}, nil
+		}
+	case '>':
+		if t.p+1 < len(t.data) && t.data[t.p:t.p+2] == ">=" {
+			t.p += 2
+			return token.Token{Kind: token.GREATEREQUAL, Content: ">="}, nil
+		} else {
+			t.p++
+			return token.Token{Kind: token.GREATER, Content: ">"}, nil
 		}
 	default:
 		if isWhiteSpace(t.data[t.p]) || isNewLine(t.data[t.p]) {
@@ -159,10 +222,9 @@ func (t *Tokenizer) TokenizeMyriad() (token.Token, error) {
 			}
 			return token.Token{Kind: token.NUMBER, Content: t.data[start:t.p]}, nil
 		} else {
-			return token.Token{}, errors.New(fmt.Sprintf("tokenize error: invalid token %c", t.data[t.p]))
+			return token.Token{}, t.errorf(t.p, "tokenize error: invalid token %c", t.data[t.p])
 		}
 	}
-	return token.Token{}, errors.New(fmt.Sprintf("tokenize error: invalid token"))
 }
 
 func (t *Tokenizer) TokenizeDockerfile() (token.Token, error) {
@@ -185,9 +247,25 @@ func (t *Tokenizer) TokenizeDockerfile() (token.Token, error) {
 		t.isInDfBlock = false
 		return token.Token{Kind: token.DFEND, Content: "-}}"}, nil
 	}
+	if t.nextTokenIs("{{/*") {
+		// Myriadコメントは出力しない
+		start := t.p
+		t.p += 4
+		for !t.nextTokenIs("*/}}") {
+			if t.p >= len(t.data) {
+				return token.Token{}, t.errorf(start, "tokenize error: cannot find '*/}}'")
+			}
+			t.p++
+		}
+		t.p += 4
+		t.skipCommentSpaces()
+		return token.Token{}, nil
+	}
 	if t.nextTokenIs("{{") {
 		t.p += 2
 		t.isInDfBlock = false
+		t.isInReplace = true
+		t.braceDepth = 0
 		return token.Token{Kind: token.LDOUBLEBRA, Content: "{{"}, nil
 	}
 
@@ -6,15 +6,24 @@ type Tokenizer struct {
 	data        string
 	p           int
 	isInDfBlock bool
+	// {{ }} による置換の中にいるか、その中で開いている { の数
+	isInReplace bool
+	braceDepth  int
 	isInCommand bool
 	commandPtr  string
 	Tokens      []token.Token
 	filePath    string
+	// 位置計算用のカーソル
+	posP      int
+	posLine   int
+	posColumn int
 }
 
 func NewTokenizer(data string, filePath string) *Tokenizer {
 	return &Tokenizer{
-		data:     data,
-		filePath: filePath,
+		data:      data,
+		filePath:  filePath,
+		posLine:   1,
+		posColumn: 1,
 	}
 }
@@ -1,6 +1,11 @@
 package tokenizer
 
-import "github.com/ty-bnn/myriad/pkg/model/token"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ty-bnn/myriad/pkg/model/token"
+)
 
 func isLetter(ch byte) bool {
 	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
@@ -36,3 +41,54 @@ func isDockerfileCommand(keyword string) bool {
 func (t *Tokenizer) nextTokenIs(word string) bool {
 	return t.p+len(word)-1 < len(t.data) && t.data[t.p:t.p+len(word)] == word
 }
+
+// skipCommentSpaces skips the blanks after a Myriad comment in a Dockerfile block
+// when the comment is at the beginning of a line, so that the line is output as if the comment did not exist.
+func (t *Tokenizer) skipCommentSpaces() {
+	if len(t.Tokens) == 0 {
+		return
+	}
+	preContent := t.Tokens[len(t.Tokens)-1].Content
+	if preContent != "\n" && preContent != "{{-" {
+		return
+	}
+
+	for t.p < len(t.data) && isWhiteSpace(t.data[t.p]) {
+		t.p++
+	}
+	if t.p >= len(t.data) || !isNewLine(t.data[t.p]) {
+		return
+	}
+
+	// コメントだけの行は改行ごと取り除く
+	for t.p < len(t.data) && (isWhiteSpace(t.data[t.p]) || isNewLine(t.data[t.p])) {
+		t.p++
+	}
+}
+
+// lineColumn returns the line and column of the given offset.
+// 呼び出しごとにオフセットは単調増加するため、前回の位置から数え直す
+func (t *Tokenizer) lineColumn(offset int) (int, int) {
+	if offset < t.posP {
+		t.posP, t.posLine, t.posColumn = 0, 1, 1
+	}
+	for ; t.posP < offset && t.posP < len(t.data); t.posP++ {
+		ch := t.data[t.posP]
+		if isNewLine(ch) {
+			t.posLine++
+			t.posColumn = 1
+		} else if ch&0xC0 != 0x80 {
+			// UTF-8の継続バイトは列に数えない
+			t.posColumn++
+		}
+	}
+	return t.posLine, t.posColumn
+}
+
+func (t *Tokenizer) errorf(offset int, format string, a ...interface{}) error {
+	line, column := t.lineColumn(offset)
+	return &token.Error{
+		Pos: token.Position{File: t.filePath, Line: line, Column: column},
+		Err: errors.New(fmt.Sprintf(format, a...)),
+	}
+}
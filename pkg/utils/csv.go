@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// UnmarshalCSV decodes CSV into an array of values.Object keyed by the header line.
+// 値は全て文字列として扱う
+func UnmarshalCSV(data []byte) (interface{}, error) {
+	// Excel などが付ける BOM が1列目のヘッダ名に混ざらないよう取り除く
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New(fmt.Sprintf("cannot find header line"))
+	}
+
+	header := records[0]
+	seen := make(map[string]bool)
+	for _, name := range header {
+		if seen[name] {
+			return nil, errors.New(fmt.Sprintf("%s is duplicated in header line", name))
+		}
+		seen[name] = true
+	}
+
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := values.NewObject()
+		for i, name := range header {
+			row.Set(name, record[i])
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
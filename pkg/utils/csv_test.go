@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name: "header and rows",
+			data: "name,version\nnode,18\npython,3.12\n",
+			want: []interface{}{
+				object("name", "node", "version", "18"),
+				object("name", "python", "version", "3.12"),
+			},
+		},
+		{
+			name: "header only",
+			data: "name,version\n",
+			want: []interface{}{},
+		},
+		{
+			name: "quoted fields",
+			data: "name,packages\n\"node\",\"curl, git\"\n\"say \"\"hi\"\"\",\"a\nb\"\n",
+			want: []interface{}{
+				object("name", "node", "packages", "curl, git"),
+				object("name", `say "hi"`, "packages", "a\nb"),
+			},
+		},
+		{
+			name: "crlf",
+			data: "name,version\r\nnode,18\r\n",
+			want: []interface{}{
+				object("name", "node", "version", "18"),
+			},
+		},
+		{
+			name: "bom",
+			data: "\ufeffname,version\nnode,18\n",
+			want: []interface{}{
+				object("name", "node", "version", "18"),
+			},
+		},
+		{
+			name: "bom before quoted header",
+			data: "\ufeff\"name\",version\nnode,18\n",
+			want: []interface{}{
+				object("name", "node", "version", "18"),
+			},
+		},
+		{name: "empty", data: "", wantErr: true},
+		{name: "duplicated header", data: "name,name\na,b\n", wantErr: true},
+		{name: "wrong number of fields", data: "name,version\nnode\n", wantErr: true},
+		{name: "bare quote", data: "name\na\"b\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalCSV([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// UnmarshalDotenv decodes a .env file into a values.Object.
+// `KEY=value`, `export KEY=value`, クォートした値とコメントに対応する
+func UnmarshalDotenv(data []byte) (interface{}, error) {
+	env := values.NewObject()
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, errors.New(fmt.Sprintf("line %d: cannot find =", i+1))
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		if !isEnvName(key) {
+			return nil, errors.New(fmt.Sprintf("line %d: invalid name %q", i+1, key))
+		}
+
+		raw := strings.TrimSpace(line[eq+1:])
+		var value string
+		switch {
+		case strings.HasPrefix(raw, `"`):
+			// ダブルクォートの値は改行を含むことができる
+			for !closedQuote(raw[1:], '"') && i+1 < len(lines) {
+				i++
+				raw += "\n" + lines[i]
+			}
+			v, err := unquoteEnv(raw, '"')
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("line %d: %s", i+1, err))
+			}
+			value = v
+		case strings.HasPrefix(raw, "'"):
+			v, err := unquoteEnv(raw, '\'')
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("line %d: %s", i+1, err))
+			}
+			value = v
+		default:
+			// クォートしていない値は ` #` 以降をコメントとする
+			if c := strings.Index(raw, " #"); c >= 0 {
+				raw = raw[:c]
+			}
+			value = strings.TrimSpace(raw)
+		}
+
+		env.Set(key, value)
+	}
+
+	return env, nil
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (i > 0 && '0' <= c && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// closedQuote reports whether s contains an unescaped quote.
+func closedQuote(s string, quote byte) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && quote == '"' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteEnv returns the quoted value at the head of s.
+// シングルクォートの中ではエスケープを解釈しない
+func unquoteEnv(s string, quote byte) (string, error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			rest := strings.TrimSpace(s[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", errors.New(fmt.Sprintf("unexpected %q after quoted value", rest))
+			}
+			return sb.String(), nil
+		}
+		if c == '\\' && quote == '"' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return "", errors.New(fmt.Sprintf("cannot find closing %c", quote))
+}
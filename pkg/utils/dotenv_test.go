@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// object returns a values.Object with the keys and values given in order.
+func object(kv ...interface{}) *values.Object {
+	o := values.NewObject()
+	for i := 0; i+1 < len(kv); i += 2 {
+		o.Set(kv[i].(string), kv[i+1])
+	}
+	return o
+}
+
+func TestUnmarshalDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *values.Object
+		wantErr bool
+	}{
+		{
+			name: "plain",
+			data: "NODE_VERSION=18\nBASE=debian\n",
+			want: object("NODE_VERSION", "18", "BASE", "debian"),
+		},
+		{
+			name: "export",
+			data: "export NODE_VERSION=18\nexport  BASE = debian\n",
+			want: object("NODE_VERSION", "18", "BASE", "debian"),
+		},
+		{
+			name: "comments and blank lines",
+			data: "# versions\n\n  # indented comment\nA=1 # inline comment\nB=x#y\n",
+			want: object("A", "1", "B", "x#y"),
+		},
+		{
+			name: "empty value",
+			data: "A=\nB=''\nC=\"\"\n",
+			want: object("A", "", "B", "", "C", ""),
+		},
+		{
+			name: "double quotes",
+			data: `A="a b # not a comment"` + "\n" + `B="line1\nline2\ttab \"q\" \\"` + "\n",
+			want: object("A", "a b # not a comment", "B", "line1\nline2\ttab \"q\" \\"),
+		},
+		{
+			name: "single quotes are literal",
+			data: `A='a\nb "c"' # comment` + "\n",
+			want: object("A", `a\nb "c"`),
+		},
+		{
+			name: "multiline double quotes",
+			data: "KEY=\"-----BEGIN-----\nabc\n-----END-----\"\nNEXT=1\n",
+			want: object("KEY", "-----BEGIN-----\nabc\n-----END-----", "NEXT", "1"),
+		},
+		{
+			name: "crlf",
+			data: "A=1\r\nB=2\r\n",
+			want: object("A", "1", "B", "2"),
+		},
+		{
+			name: "value with =",
+			data: "OPTS=--a=1 --b=2\n",
+			want: object("OPTS", "--a=1 --b=2"),
+		},
+		{
+			name: "later value overrides",
+			data: "A=1\nB=2\nA=3\n",
+			want: object("A", "3", "B", "2"),
+		},
+		{name: "missing =", data: "A\n", wantErr: true},
+		{name: "invalid name", data: "1A=1\n", wantErr: true},
+		{name: "unclosed double quote", data: "A=\"abc\n", wantErr: true},
+		{name: "unclosed single quote", data: "A='abc\n", wantErr: true},
+		{name: "text after quote", data: "A=\"abc\" def\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalDotenv([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalDotenv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalDotenv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
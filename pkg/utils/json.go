@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// UnmarshalJSON decodes JSON like json.Unmarshal, but decodes objects into values.Object
+// so that the order of the keys in the file is kept.
+func UnmarshalJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	value, err := decodeJSON(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err == nil {
+		return nil, errors.New(fmt.Sprintf("invalid character after top-level value"))
+	}
+
+	return value, nil
+}
+
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// 文字列, 数値, 真偽値, null
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		object := values.NewObject()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(keyTok.(string), value)
+		}
+		// }
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case '[':
+		array := make([]interface{}, 0)
+		for dec.More() {
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		// ]
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("unexpected %s", delim))
+}
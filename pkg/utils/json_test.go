@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "keeps key order",
+			data: `{"b": 1, "a": 2, "c": 3}`,
+			want: object("b", float64(1), "a", float64(2), "c", float64(3)),
+		},
+		{
+			name: "nested",
+			data: `{"18": {"variants": ["ubuntu", "debian"], "lts": true, "eol": null}}`,
+			want: object("18", object("variants", []interface{}{"ubuntu", "debian"}, "lts", true, "eol", nil)),
+		},
+		{
+			name: "escapes",
+			data: `{"s": "a\"b\\c\né"}`,
+			want: object("s", "a\"b\\c\né"),
+		},
+		{
+			name: "top-level array",
+			data: `[1, "two", [], {}]`,
+			want: []interface{}{float64(1), "two", []interface{}{}, object()},
+		},
+		{
+			name: "scalar",
+			data: `"node"`,
+			want: "node",
+		},
+		{name: "empty", data: "", wantErr: true},
+		{name: "trailing value", data: `{} {}`, wantErr: true},
+		{name: "trailing comma", data: `{"a": 1,}`, wantErr: true},
+		{name: "unclosed", data: `{"a": [1, 2}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a version number such as 1.21.3.
+// Parts は書かれている数字だけを持つ (1.21 なら [1, 21])
+type Version struct {
+	Parts []int
+	// Pre は 1.22rc1, 1.22.0-beta.2 のようなプレリリースの識別子 (rc1, beta.2)
+	Pre string
+}
+
+// ParseVersion parses a version, accepting Docker style tags such as v1.20 and 1.20-alpine.
+// 数字の後ろの alpha, beta, rc はプレリリースとし、-alpine などそれ以外は比較に使わない
+func ParseVersion(tag string) (Version, error) {
+	s := strings.TrimPrefix(tag, "v")
+
+	var parts []int
+	for len(parts) < 3 {
+		end := 0
+		for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			return Version{}, errors.New(fmt.Sprintf("%s is not a version", tag))
+		}
+
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			return Version{}, errors.New(fmt.Sprintf("%s is not a version", tag))
+		}
+		parts = append(parts, n)
+		s = s[end:]
+
+		if !strings.HasPrefix(s, ".") || len(parts) == 3 {
+			break
+		}
+		s = s[1:]
+	}
+
+	// 残りは -alpine, +build, rc1 のような接尾辞だけを許す
+	if s != "" && s[0] != '-' && s[0] != '+' && s[0] != '_' && !isLetter(s[0]) {
+		return Version{}, errors.New(fmt.Sprintf("%s is not a version", tag))
+	}
+
+	return Version{Parts: parts, Pre: prerelease(s)}, nil
+}
+
+// prerelease returns the prerelease identifier at the head of suffix, or "" if there is none.
+func prerelease(suffix string) string {
+	suffix = strings.TrimLeft(suffix, "-.")
+	if end := strings.IndexAny(suffix, "-+_"); end >= 0 {
+		suffix = suffix[:end]
+	}
+
+	for _, name := range preNames {
+		if strings.HasPrefix(strings.ToLower(suffix), name) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// プレリリースの名前を順序の低い方から並べたもの
+var preNames = []string{"alpha", "beta", "rc"}
+
+// comparePre compares prerelease identifiers. プレリリースの無い方が新しい
+func comparePre(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	rank := func(pre string) (int, int) {
+		pre = strings.ToLower(pre)
+		for i, name := range preNames {
+			if strings.HasPrefix(pre, name) {
+				// rc1, rc.1 のどちらも番号1とする
+				n, _ := strconv.Atoi(strings.TrimLeft(pre[len(name):], "."))
+				return i, n
+			}
+		}
+		return 0, 0
+	}
+
+	aName, aNum := rank(a)
+	bName, bNum := rank(b)
+	if aName != bName {
+		return sign(aName - bName)
+	}
+	if aNum != bNum {
+		return sign(aNum - bNum)
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	if n > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Part returns the i-th number of the version. 書かれていない部分は0とする
+func (v Version) Part(i int) int {
+	if i < len(v.Parts) {
+		return v.Parts[i]
+	}
+	return 0
+}
+
+// CompareVersions returns -1, 0 or 1 comparing the first n numbers of a and b.
+// 残りの数字が両方とも0の場合だけプレリリースを比べる (1.22.0-rc1 は 1.22 より前, 1.22.5-rc1 は 1.22 の中)
+func CompareVersions(a Version, b Version, n int) int {
+	for i := 0; i < n; i++ {
+		if a.Part(i) < b.Part(i) {
+			return -1
+		}
+		if a.Part(i) > b.Part(i) {
+			return 1
+		}
+	}
+
+	for i := n; i < 3; i++ {
+		if a.Part(i) != 0 || b.Part(i) != 0 {
+			return 0
+		}
+	}
+	return comparePre(a.Pre, b.Pre)
+}
+
+// MatchVersion reports whether tag satisfies constraint such as ">=1.21" or ">=1.21 <1.23".
+// 制約はカンマか空白で区切り、全てを満たす場合にtrueとする
+// 書かれていない桁は範囲として扱う。1.21 は 1.21.0 以上 1.22.0 未満を表すので、
+// "<=1.21" と "==1.21" は 1.21.5 を満たし、">1.21" は 1.21.5 を満たさない
+func MatchVersion(tag string, constraint string) (bool, error) {
+	v, err := ParseVersion(tag)
+	if err != nil {
+		return false, err
+	}
+
+	constraints, err := splitConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range constraints {
+		op := ""
+		for _, candidate := range constraintOps {
+			if strings.HasPrefix(c, candidate) {
+				op = candidate
+				break
+			}
+		}
+
+		want, err := ParseVersion(strings.TrimSpace(c[len(op):]))
+		if err != nil {
+			return false, errors.New(fmt.Sprintf("invalid constraint %q", constraint))
+		}
+
+		cmp := CompareVersions(v, want, len(want.Parts))
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+var constraintOps = []string{">=", "<=", "==", "!=", ">", "<", "="}
+
+// splitConstraint splits constraint at commas and spaces.
+// ">= 1.21" のように演算子の後ろに空白がある場合は次の語とつなげる
+func splitConstraint(constraint string) ([]string, error) {
+	var constraints []string
+	op := ""
+	for _, field := range strings.Fields(strings.ReplaceAll(constraint, ",", " ")) {
+		if isOp(field) {
+			if op != "" {
+				return nil, errors.New(fmt.Sprintf("invalid constraint %q", constraint))
+			}
+			op = field
+			continue
+		}
+		constraints = append(constraints, op+field)
+		op = ""
+	}
+
+	if op != "" || len(constraints) == 0 {
+		return nil, errors.New(fmt.Sprintf("invalid constraint %q", constraint))
+	}
+	return constraints, nil
+}
+
+func isOp(s string) bool {
+	for _, op := range constraintOps {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
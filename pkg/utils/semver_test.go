@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    Version
+		wantErr bool
+	}{
+		{tag: "1.21.3", want: Version{Parts: []int{1, 21, 3}}},
+		{tag: "v1.20", want: Version{Parts: []int{1, 20}}},
+		{tag: "18", want: Version{Parts: []int{18}}},
+		{tag: "1.20-alpine", want: Version{Parts: []int{1, 20}}},
+		{tag: "3.12-slim-bookworm", want: Version{Parts: []int{3, 12}}},
+		{tag: "1.2.3.4", wantErr: true},
+		{tag: "1.22rc1", want: Version{Parts: []int{1, 22}, Pre: "rc1"}},
+		{tag: "1.22.0-beta.2", want: Version{Parts: []int{1, 22, 0}, Pre: "beta.2"}},
+		{tag: "1.22.0-rc.1-alpine", want: Version{Parts: []int{1, 22, 0}, Pre: "rc.1"}},
+		{tag: "1.2.3+build5", want: Version{Parts: []int{1, 2, 3}}},
+		{tag: "latest", wantErr: true},
+		{tag: "", wantErr: true},
+		{tag: "1.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		tag        string
+		constraint string
+		want       bool
+		wantErr    bool
+	}{
+		{tag: "1.21.0", constraint: ">=1.21", want: true},
+		{tag: "1.20.9", constraint: ">=1.21", want: false},
+		{tag: "1.21-alpine", constraint: ">=1.21", want: true},
+		{tag: "v1.22.1", constraint: "<1.23", want: true},
+		{tag: "1.23.0", constraint: "<1.23", want: false},
+
+		// 書かれていない桁は範囲として扱う
+		{tag: "1.21.5", constraint: ">1.21", want: false},
+		{tag: "1.22.0", constraint: ">1.21", want: true},
+		{tag: "1.21.5", constraint: "<=1.21", want: true},
+		{tag: "1.21.5", constraint: "==1.21", want: true},
+		{tag: "1.21.5", constraint: "=1.21", want: true},
+		{tag: "1.21.5", constraint: "1.21", want: true},
+		{tag: "1.21.5", constraint: "!=1.21", want: false},
+		{tag: "1.21.5", constraint: "==1.21.4", want: false},
+
+		// カンマ区切りと空白区切り
+		{tag: "1.22.3", constraint: ">=1.21, <1.23", want: true},
+		{tag: "1.22.3", constraint: ">=1.21 <1.23", want: true},
+		{tag: "1.23.0", constraint: ">=1.21 <1.23", want: false},
+		{tag: "1.22.3", constraint: ">= 1.21, < 1.23", want: true},
+
+		// プレリリースはリリースより前
+		{tag: "1.22.0-rc1", constraint: ">=1.22", want: false},
+		{tag: "1.22rc1", constraint: "<1.22", want: true},
+		{tag: "1.22.5-rc1", constraint: ">=1.22", want: true},
+		{tag: "1.22rc2", constraint: ">=1.22rc1", want: true},
+		{tag: "1.22beta1", constraint: ">=1.22rc1", want: false},
+
+		{tag: "1.22", constraint: ">=x", wantErr: true},
+		{tag: "1.22", constraint: ">=", wantErr: true},
+		{tag: "1.22", constraint: ">= >= 1.21", wantErr: true},
+		{tag: "1.22", constraint: "", wantErr: true},
+		{tag: "latest", constraint: ">=1.21", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchVersion(tt.tag, tt.constraint)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MatchVersion(%q, %q) error = %v, wantErr %v", tt.tag, tt.constraint, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MatchVersion(%q, %q) = %v, want %v", tt.tag, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "1.2", b: "1.2.0", want: 0},
+		{a: "1.10", b: "1.9", want: 1},
+		{a: "1.2.3", b: "1.3", want: -1},
+		{a: "1.20-alpine", b: "1.20", want: 0},
+
+		// プレリリースの順序
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-beta", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{a: "1.0.0-beta.11", b: "1.0.0-rc.1", want: -1},
+		{a: "1.0.0-rc.1", b: "1.0.0", want: -1},
+		{a: "1.0.0", b: "1.0.0-rc.1", want: 1},
+		{a: "1.0.1-rc.1", b: "1.0.0", want: 1},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", tt.a, err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", tt.b, err)
+		}
+		if got := CompareVersions(a, b, 3); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -29,6 +29,24 @@ func ReadLinesFromFile(samplePath string) (string, error) {
 	return string(data), nil
 }
 
+// ResolvePath finds the file referenced from a file in baseDir.
+// 相対パスは参照元ファイルのディレクトリ、検索パスの順に探す
+func ResolvePath(name string, baseDir string, searchPath []string) (string, error) {
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+
+	dirs := append([]string{baseDir}, searchPath...)
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", errors.New(fmt.Sprintf("cannot find %s", name))
+}
+
 func WriteFile(codes []string, filePath string) error {
 	// Create file.
 	dirs := filepath.Dir(filePath)
@@ -0,0 +1,693 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// Unmarshal decodes the documents in a YAML file.
+// JsonUnmarshalと同じく、mappingはvalues.Object、sequenceは[]interface{}、
+// スカラーはstring, float64, bool, nilになる
+//
+// 対応しているのはブロック、フロー形式のmappingとsequence、引用符付きのスカラー、
+// ブロックスカラー (| と >)、コメント、"---" で区切った複数のドキュメント
+// アンカー、エイリアス、タグには対応していない
+func Unmarshal(data []byte) ([]interface{}, error) {
+	var docs []interface{}
+	for _, docLines := range splitDocuments(string(data)) {
+		d := &decoder{lines: docLines}
+		d.skipBlankLines()
+		if d.index >= len(d.lines) {
+			// 空のドキュメント
+			docs = append(docs, nil)
+			continue
+		}
+
+		value, err := d.node(d.lines[d.index].indent)
+		if err != nil {
+			return nil, err
+		}
+
+		d.skipBlankLines()
+		if d.index < len(d.lines) {
+			return nil, d.errorf("unexpected indentation")
+		}
+
+		docs = append(docs, value)
+	}
+
+	return docs, nil
+}
+
+type line struct {
+	num    int
+	indent int
+	// text はインデントとコメントを除いた内容
+	text string
+	raw  string
+}
+
+type decoder struct {
+	lines []line
+	index int
+}
+
+// splitDocuments splits the file into the lines of each document.
+func splitDocuments(data string) [][]line {
+	var docs [][]line
+	var current []line
+	started := false
+
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(raw, "%") && !started {
+			// ディレクティブ
+			continue
+		}
+
+		if raw == "---" || strings.HasPrefix(raw, "--- ") || strings.HasPrefix(raw, "---\t") {
+			if started || hasContent(current) {
+				docs = append(docs, current)
+			}
+			current = nil
+			started = true
+
+			// "--- value" の形
+			rest := strings.TrimSpace(raw[3:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				current = append(current, newLine(i+1, rest))
+			}
+			continue
+		}
+
+		if raw == "..." || strings.HasPrefix(raw, "... ") {
+			if started || hasContent(current) {
+				docs = append(docs, current)
+			}
+			current = nil
+			started = false
+			continue
+		}
+
+		current = append(current, newLine(i+1, raw))
+	}
+
+	if started || hasContent(current) {
+		docs = append(docs, current)
+	}
+
+	return docs
+}
+
+func newLine(num int, raw string) line {
+	indent := 0
+	for indent < len(raw) && raw[indent] == ' ' {
+		indent++
+	}
+
+	return line{
+		num:    num,
+		indent: indent,
+		text:   strings.TrimRight(stripComment(raw[indent:]), " \t"),
+		raw:    raw,
+	}
+}
+
+// tabIndented reports whether a tab is used in the indentation of the line.
+func (l line) tabIndented() bool {
+	return l.text != "" && l.indent < len(l.raw) && l.raw[l.indent] == '\t'
+}
+
+func hasContent(lines []line) bool {
+	for _, l := range lines {
+		if l.text != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripComment removes a comment that is not in quotes.
+// '#' は行頭か空白の直後にある場合だけコメントになる
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '\t' {
+				return text[:i]
+			}
+		}
+	}
+
+	return text
+}
+
+func (d *decoder) errorf(format string, a ...interface{}) error {
+	num := 0
+	if d.index < len(d.lines) {
+		num = d.lines[d.index].num
+	} else if len(d.lines) > 0 {
+		num = d.lines[len(d.lines)-1].num
+	}
+
+	return errors.New(fmt.Sprintf("yaml: line %d: %s", num, fmt.Sprintf(format, a...)))
+}
+
+func (d *decoder) skipBlankLines() {
+	for d.index < len(d.lines) && d.lines[d.index].text == "" {
+		d.index++
+	}
+}
+
+// node decodes the block node that starts at the current line.
+func (d *decoder) node(indent int) (interface{}, error) {
+	d.skipBlankLines()
+	l := d.lines[d.index]
+	if l.tabIndented() {
+		return nil, d.errorf("tabs cannot be used for indentation")
+	}
+
+	if isSequenceEntry(l.text) {
+		return d.sequence(indent)
+	}
+
+	if _, _, ok := splitMappingEntry(l.text); ok {
+		return d.mapping(indent)
+	}
+
+	// スカラーかフロー形式
+	d.index++
+	return d.inlineValue(l.text, indent)
+}
+
+func isSequenceEntry(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// sequence decodes the "- item" lines at indent.
+func (d *decoder) sequence(indent int) (interface{}, error) {
+	items := make([]interface{}, 0)
+	for {
+		d.skipBlankLines()
+		if d.index >= len(d.lines) {
+			break
+		}
+		l := d.lines[d.index]
+		if l.tabIndented() {
+			return nil, d.errorf("tabs cannot be used for indentation")
+		}
+		if l.indent != indent || !isSequenceEntry(l.text) {
+			if l.indent > indent {
+				return nil, d.errorf("unexpected indentation")
+			}
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			// 要素は次の行から始まる
+			d.index++
+			item, err := d.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// "- key: value" や "- - item" は、"- " の後ろをインデントの深い行として読み直す
+		offset := len(l.text) - len(rest)
+		d.lines[d.index] = line{num: l.num, indent: indent + offset, text: rest, raw: strings.Repeat(" ", indent+offset) + rest}
+		item, err := d.node(indent + offset)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// mapping decodes the "key: value" lines at indent.
+func (d *decoder) mapping(indent int) (interface{}, error) {
+	object := values.NewObject()
+	for {
+		d.skipBlankLines()
+		if d.index >= len(d.lines) {
+			break
+		}
+		l := d.lines[d.index]
+		if l.tabIndented() {
+			return nil, d.errorf("tabs cannot be used for indentation")
+		}
+		if l.indent != indent {
+			if l.indent > indent {
+				return nil, d.errorf("unexpected indentation")
+			}
+			break
+		}
+
+		key, rest, ok := splitMappingEntry(l.text)
+		if !ok {
+			if isSequenceEntry(l.text) {
+				break
+			}
+			return nil, d.errorf("cannot find ':' in a mapping")
+		}
+		if _, has := object.Get(key); has {
+			return nil, d.errorf("duplicate key %s", key)
+		}
+		d.index++
+
+		var value interface{}
+		var err error
+		switch {
+		case rest == "":
+			value, err = d.mappingValue(indent)
+		case strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">"):
+			value, err = d.blockScalar(rest, indent)
+		default:
+			value, err = d.inlineValue(rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		object.Set(key, value)
+	}
+
+	return object, nil
+}
+
+// mappingValue decodes the value of "key:" that starts at the next line.
+// "key:" の直後に同じインデントで "- item" が続く書き方も受け付ける
+func (d *decoder) mappingValue(indent int) (interface{}, error) {
+	d.skipBlankLines()
+	if d.index < len(d.lines) && d.lines[d.index].indent == indent && isSequenceEntry(d.lines[d.index].text) {
+		return d.sequence(indent)
+	}
+
+	return d.child(indent)
+}
+
+// child decodes the node indented deeper than indent, or returns null if there is none.
+func (d *decoder) child(indent int) (interface{}, error) {
+	d.skipBlankLines()
+	if d.index >= len(d.lines) || d.lines[d.index].indent <= indent {
+		return nil, nil
+	}
+
+	return d.node(d.lines[d.index].indent)
+}
+
+// splitMappingEntry splits "key: value" into the key and the rest.
+func splitMappingEntry(text string) (string, string, bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' || isSequenceEntry(text) {
+		return "", "", false
+	}
+
+	// 引用符付きのキー
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text, 0)
+		if end < 0 {
+			return "", "", false
+		}
+		rest := strings.TrimLeft(text[end+1:], " ")
+		if !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ') {
+			return "", "", false
+		}
+		key, err := quotedScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// closingQuote returns the index of the quote that closes the quote at start.
+func closingQuote(text string, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote:
+			// '' はシングルクォートのエスケープ
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+
+	return -1
+}
+
+// inlineValue decodes a scalar or a flow collection written after "key:" or "- ".
+// 閉じていないフロー形式は次の行に続けて読む
+func (d *decoder) inlineValue(text string, indent int) (interface{}, error) {
+	if text[0] == '[' || text[0] == '{' {
+		for !flowClosed(text) {
+			if d.index >= len(d.lines) {
+				return nil, d.errorf("cannot find the end of %c", text[0])
+			}
+			text += " " + d.lines[d.index].text
+			d.index++
+		}
+
+		f := &flowDecoder{text: text}
+		value, err := f.value()
+		if err != nil {
+			return nil, d.errorf("%s", err)
+		}
+		f.skipSpaces()
+		if f.pos < len(f.text) {
+			return nil, d.errorf("unexpected %s after a flow collection", f.text[f.pos:])
+		}
+		return value, nil
+	}
+
+	if text[0] == '&' || text[0] == '*' || text[0] == '!' {
+		return nil, d.errorf("anchors, aliases and tags are not supported")
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text, 0)
+		if end < 0 || strings.TrimSpace(text[end+1:]) != "" {
+			return nil, d.errorf("invalid quoted scalar %s", text)
+		}
+		return quotedScalar(text)
+	}
+
+	return plainScalar(text), nil
+}
+
+func flowClosed(text string) bool {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"', '\'':
+			end := closingQuote(text, i)
+			if end < 0 {
+				return false
+			}
+			i = end
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
+
+// blockScalar decodes the lines of "|" (literal) or ">" (folded) block scalar.
+func (d *decoder) blockScalar(header string, indent int) (interface{}, error) {
+	literal := header[0] == '|'
+	chomping := byte(0)
+	for _, c := range []byte(strings.TrimSpace(header[1:])) {
+		switch c {
+		case '-', '+':
+			chomping = c
+		default:
+			if c < '1' || '9' < c {
+				return nil, d.errorf("invalid block scalar header %s", header)
+			}
+		}
+	}
+
+	// 内容のインデントは最初の空でない行で決まる
+	var contents []string
+	blockIndent := -1
+	for d.index < len(d.lines) {
+		l := d.lines[d.index]
+		if strings.TrimSpace(l.raw) == "" {
+			contents = append(contents, "")
+			d.index++
+			continue
+		}
+		if blockIndent < 0 {
+			if l.indent <= indent {
+				break
+			}
+			blockIndent = l.indent
+		}
+		if l.indent < blockIndent {
+			break
+		}
+		contents = append(contents, l.raw[blockIndent:])
+		d.index++
+	}
+
+	// 末尾の空行は内容に含めず、chompingで扱う
+	trailing := 0
+	for len(contents) > 0 && contents[len(contents)-1] == "" {
+		contents = contents[:len(contents)-1]
+		trailing++
+	}
+	if blockIndent < 0 {
+		return "", nil
+	}
+
+	var text string
+	if literal {
+		text = strings.Join(contents, "\n")
+	} else {
+		// 空行は改行になり、それ以外の改行は空白になる
+		var b strings.Builder
+		for i, content := range contents {
+			if content == "" {
+				b.WriteString("\n")
+				continue
+			}
+			if i > 0 && contents[i-1] != "" {
+				b.WriteString(" ")
+			}
+			b.WriteString(content)
+		}
+		text = b.String()
+	}
+
+	switch chomping {
+	case '-':
+		return text, nil
+	case '+':
+		return text + strings.Repeat("\n", trailing+1), nil
+	}
+
+	return text + "\n", nil
+}
+
+// flowDecoder decodes flow collections such as [a, b] and {k: v}.
+type flowDecoder struct {
+	text string
+	pos  int
+}
+
+func (f *flowDecoder) skipSpaces() {
+	for f.pos < len(f.text) && (f.text[f.pos] == ' ' || f.text[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flowDecoder) value() (interface{}, error) {
+	f.skipSpaces()
+	if f.pos >= len(f.text) {
+		return nil, errors.New("unexpected end of a flow collection")
+	}
+
+	switch f.text[f.pos] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		end := closingQuote(f.text, f.pos)
+		if end < 0 {
+			return nil, errors.New(fmt.Sprintf("invalid quoted scalar %s", f.text[f.pos:]))
+		}
+		quoted := f.text[f.pos : end+1]
+		f.pos = end + 1
+		return quotedScalar(quoted)
+	}
+
+	// プレーンスカラーは ',' ']' '}' か ": " の前まで
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" ,]}", rune(f.text[f.pos+1]))) {
+			break
+		}
+		f.pos++
+	}
+
+	return plainScalar(strings.TrimSpace(f.text[start:f.pos])), nil
+}
+
+func (f *flowDecoder) sequence() (interface{}, error) {
+	// [
+	f.pos++
+
+	items := make([]interface{}, 0)
+	for {
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == ']' {
+			f.pos++
+			return items, nil
+		}
+
+		item, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		f.skipSpaces()
+		if f.pos >= len(f.text) {
+			return nil, errors.New("cannot find ']'")
+		}
+		switch f.text[f.pos] {
+		case ',':
+			f.pos++
+		case ']':
+		default:
+			return nil, errors.New(fmt.Sprintf("unexpected %c in a flow sequence", f.text[f.pos]))
+		}
+	}
+}
+
+func (f *flowDecoder) mapping() (interface{}, error) {
+	// {
+	f.pos++
+
+	object := values.NewObject()
+	for {
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == '}' {
+			f.pos++
+			return object, nil
+		}
+
+		keyValue, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		key := scalarString(keyValue)
+
+		f.skipSpaces()
+		var value interface{}
+		if f.pos < len(f.text) && f.text[f.pos] == ':' {
+			f.pos++
+			f.skipSpaces()
+			if f.pos < len(f.text) && f.text[f.pos] != ',' && f.text[f.pos] != '}' {
+				value, err = f.value()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if _, has := object.Get(key); has {
+			return nil, errors.New(fmt.Sprintf("duplicate key %s", key))
+		}
+		object.Set(key, value)
+
+		f.skipSpaces()
+		if f.pos >= len(f.text) {
+			return nil, errors.New("cannot find '}'")
+		}
+		switch f.text[f.pos] {
+		case ',':
+			f.pos++
+		case '}':
+		default:
+			return nil, errors.New(fmt.Sprintf("unexpected %c in a flow mapping", f.text[f.pos]))
+		}
+	}
+}
+
+// scalarString returns the text of a scalar used as a mapping key.
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func quotedScalar(text string) (string, error) {
+	if text[0] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	unquoted, err := strconv.Unquote(text)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid quoted scalar %s", text))
+	}
+
+	return unquoted, nil
+}
+
+// plainScalar resolves the type of a scalar without quotes.
+// 小数は "1.20" のようなバージョンを崩さないよう、書かれた文字列のまま扱う
+func plainScalar(text string) interface{} {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return float64(n)
+	}
+	if strings.HasPrefix(text, "0x") {
+		if n, err := strconv.ParseInt(text[2:], 16, 64); err == nil {
+			return float64(n)
+		}
+	}
+	if strings.HasPrefix(text, "0o") {
+		if n, err := strconv.ParseInt(text[2:], 8, 64); err == nil {
+			return float64(n)
+		}
+	}
+
+	return text
+}
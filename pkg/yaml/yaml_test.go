@@ -0,0 +1,185 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ty-bnn/myriad/pkg/model/values"
+)
+
+// object returns a values.Object with the keys and values given in order.
+func object(kv ...interface{}) *values.Object {
+	o := values.NewObject()
+	for i := 0; i+1 < len(kv); i += 2 {
+		o.Set(kv[i].(string), kv[i+1])
+	}
+	return o
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []interface{}
+		wantErr bool
+	}{
+		// ブロック形式
+		{
+			name: "mapping keeps key order",
+			data: "b: 1\na: two\nc: true\n",
+			want: []interface{}{object("b", float64(1), "a", "two", "c", true)},
+		},
+		{
+			name: "nested mapping and sequence",
+			data: "node:\n  versions:\n    - 18\n    - 20\n  base: debian\n",
+			want: []interface{}{object("node", object("versions", []interface{}{float64(18), float64(20)}, "base", "debian"))},
+		},
+		{
+			name: "sequence at the same indent as its key",
+			data: "variants:\n- ubuntu\n- debian\nlts: yes\n",
+			want: []interface{}{object("variants", []interface{}{"ubuntu", "debian"}, "lts", "yes")},
+		},
+		{
+			name: "sequence of mappings",
+			data: "- name: node\n  tag: 18\n- name: python\n  tag: 3.12\n",
+			want: []interface{}{[]interface{}{
+				object("name", "node", "tag", float64(18)),
+				object("name", "python", "tag", "3.12"),
+			}},
+		},
+		{
+			name: "nested sequences",
+			data: "- - a\n  - b\n-\n  - c\n",
+			want: []interface{}{[]interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}},
+		},
+		{
+			name: "empty value is null",
+			data: "a:\nb: ~\nc: null\n",
+			want: []interface{}{object("a", nil, "b", nil, "c", nil)},
+		},
+
+		// スカラー
+		{
+			name: "decimals stay strings",
+			data: "a: 1.20\nb: 010\nc: 0x1f\nd: 0o17\ne: -3\n",
+			want: []interface{}{object("a", "1.20", "b", float64(10), "c", float64(31), "d", float64(15), "e", float64(-3))},
+		},
+		{
+			name: "quoted scalars",
+			data: "a: \"18\"\nb: 'it''s'\nc: \"tab\\there\\n\"\nd: \"say \\\"hi\\\"\"\ne: 'no \\n escape'\nf: \"\\u00e9\"\n",
+			want: []interface{}{object("a", "18", "b", "it's", "c", "tab\there\n", "d", `say "hi"`, "e", `no \n escape`, "f", "é")},
+		},
+		{
+			name: "quoted keys",
+			data: "\"a: b\": 1\n'c''d': 2\n",
+			want: []interface{}{object("a: b", float64(1), "c'd", float64(2))},
+		},
+		{
+			name: "comments",
+			data: "# head\na: 1 # trailing\nb: x#y\nc: \"# not a comment\"\nd: '#' # comment\n",
+			want: []interface{}{object("a", float64(1), "b", "x#y", "c", "# not a comment", "d", "#")},
+		},
+		{
+			name: "colon in a value",
+			data: "image: node:18\nurl: http://example.com\n",
+			want: []interface{}{object("image", "node:18", "url", "http://example.com")},
+		},
+
+		// ブロックスカラー
+		{
+			name: "literal block scalar",
+			data: "run: |\n  apt-get update\n  apt-get install -y curl\nnext: 1\n",
+			want: []interface{}{object("run", "apt-get update\napt-get install -y curl\n", "next", float64(1))},
+		},
+		{
+			name: "folded block scalar",
+			data: "desc: >\n  a\n  b\n\n  c\n",
+			want: []interface{}{object("desc", "a b\nc\n")},
+		},
+		{
+			name: "chomping",
+			data: "strip: |-\n  a\n\nkeep: |+\n  b\n\nclip: |\n  c\n",
+			want: []interface{}{object("strip", "a", "keep", "b\n\n", "clip", "c\n")},
+		},
+		{
+			name: "block scalar keeps deeper indentation",
+			data: "s: |\n  a\n    b\n",
+			want: []interface{}{object("s", "a\n  b\n")},
+		},
+
+		// フロー形式
+		{
+			name: "flow collections",
+			data: "a: [1, two, \"3, 4\"]\nb: {x: 1, 'y': [true, null]}\nc: []\nd: {}\n",
+			want: []interface{}{object(
+				"a", []interface{}{float64(1), "two", "3, 4"},
+				"b", object("x", float64(1), "y", []interface{}{true, nil}),
+				"c", []interface{}{},
+				"d", object(),
+			)},
+		},
+		{
+			name: "flow collection over lines",
+			data: "a: [\n  1,\n  2\n]\nb: 3\n",
+			want: []interface{}{object("a", []interface{}{float64(1), float64(2)}, "b", float64(3))},
+		},
+		{
+			name: "flow mapping with colon in value",
+			data: "a: {image: node:18, empty:}\n",
+			want: []interface{}{object("a", object("image", "node:18", "empty", nil))},
+		},
+		{
+			name: "top-level flow sequence",
+			data: "[a, [b, c]]\n",
+			want: []interface{}{[]interface{}{"a", []interface{}{"b", "c"}}},
+		},
+
+		// ドキュメント
+		{
+			name: "multiple documents",
+			data: "a: 1\n---\nb: 2\n--- 3\n",
+			want: []interface{}{object("a", float64(1)), object("b", float64(2)), float64(3)},
+		},
+		{
+			name: "directive and document end",
+			data: "%YAML 1.2\n---\na: 1\n...\n",
+			want: []interface{}{object("a", float64(1))},
+		},
+		{
+			name: "empty document",
+			data: "---\n---\na: 1\n",
+			want: []interface{}{nil, object("a", float64(1))},
+		},
+		{
+			name: "crlf",
+			data: "a: 1\r\nb:\r\n  - x\r\n",
+			want: []interface{}{object("a", float64(1), "b", []interface{}{"x"})},
+		},
+
+		// エラー
+		{name: "anchor", data: "a: &x 1\n", wantErr: true},
+		{name: "alias", data: "a: 1\nb: *x\n", wantErr: true},
+		{name: "tag", data: "a: !!str 1\n", wantErr: true},
+		{name: "tab indentation", data: "a:\n\tb: 1\n", wantErr: true},
+		{name: "duplicate key", data: "a: 1\na: 2\n", wantErr: true},
+		{name: "duplicate key in flow mapping", data: "{a: 1, a: 2}\n", wantErr: true},
+		{name: "unexpected indentation", data: "a: 1\n  b: 2\n", wantErr: true},
+		{name: "missing colon", data: "a: 1\nb\n", wantErr: true},
+		{name: "unclosed flow sequence", data: "a: [1, 2\n", wantErr: true},
+		{name: "unclosed quote", data: "a: \"abc\n", wantErr: true},
+		{name: "text after quoted scalar", data: "a: \"abc\" def\n", wantErr: true},
+		{name: "invalid block scalar header", data: "a: |x\n  b\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unmarshal([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}